@@ -2,8 +2,9 @@ module github.com/felipeek/brasileirao-simulation
 
 go 1.22.5
 
+require github.com/bit101/go-ansi v1.5.4
+
 require (
-	github.com/bit101/go-ansi v1.5.4 // indirect
 	golang.org/x/sys v0.13.0 // indirect
 	golang.org/x/term v0.13.0 // indirect
 )
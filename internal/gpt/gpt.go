@@ -28,8 +28,8 @@ const (
 	MESSAGE_CATEGORY_INJURY        messageCategory = "MEDICAL_DEPARTMENT"
 )
 
-func GptRetrieveMessage(apiKey string, teamName string, attributeName string, attributeDescription string, valueDiff float64) (string, error) {
-	messageCategory := util.RandomChoice(MESSAGE_CATEGORY_CONTROVERSIAL, MESSAGE_CATEGORY_FUNNY, MESSAGE_CATEGORY_INJURY).(messageCategory)
+func GptRetrieveMessage(rng *util.Rng, apiKey string, teamName string, attributeName string, attributeDescription string, valueDiff float64) (string, error) {
+	messageCategory := util.RandomChoice(rng, MESSAGE_CATEGORY_CONTROVERSIAL, MESSAGE_CATEGORY_FUNNY, MESSAGE_CATEGORY_INJURY).(messageCategory)
 
 	signal := '+'
 	if valueDiff < 0 {
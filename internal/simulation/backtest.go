@@ -0,0 +1,307 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Added to the count of each outcome, so no outcome has zero probability (which would make the log-loss infinite)
+	BACKTEST_PSEUDO_COUNT = 0.5
+	// Width of the calibration buckets
+	BACKTEST_BUCKET_WIDTH = 0.1
+)
+
+const (
+	OUTCOME_HOME_WIN = iota
+	OUTCOME_DRAW
+	OUTCOME_AWAY_WIN
+	NUM_OUTCOMES
+)
+
+var outcomeNames = [NUM_OUTCOMES]string{"home win", "draw", "away win"}
+
+type BacktestOptions struct {
+	// CSV file with the fixtures and results of a past season, in the same format as -fixtures
+	FixturesFile string
+	// Number of simulations of the remaining fixtures after each round
+	NumSimulations int
+	Seed           uint64
+	MatchModel     string
+	ScoreModel     string
+	// Directory with the league teams. If empty, the dataset of Season (DATASET_CURRENT_SEASON by default) is used
+	TeamsDir string
+	// Season of the dataset with the league teams (see DATASETS_PATH), e.g. 2024
+	Season string
+	// Directory with the datasets. If empty, DATASETS_PATH is looked up in the working directory and next to the executable
+	DatasetsDir string
+}
+
+// Probabilities of the outcomes of a fixture, predicted before it was played, and its actual outcome
+type BacktestPrediction struct {
+	Probabilities [NUM_OUTCOMES]float64
+	Outcome       int
+	// Rounds between the last known round and the round of the fixture
+	Horizon int
+}
+
+type BacktestScores struct {
+	Predictions int
+	Brier       float64
+	Rps         float64
+	LogLoss     float64
+}
+
+// For every round of a past season, simulates the remaining fixtures from the results known so far,
+// and compares the predicted outcome probabilities with the actual results
+func Backtest(options BacktestOptions) {
+	if options.FixturesFile == "" {
+		fmt.Fprintf(os.Stderr, "A CSV file with the fixtures and results of a past season is required (-fixtures)\n")
+		os.Exit(1)
+	}
+
+	if options.NumSimulations <= 0 {
+		fmt.Fprintf(os.Stderr, "Number of simulations must be positive\n")
+		os.Exit(1)
+	}
+
+	scoreModel, err := scoreModelGetWithName(options.ScoreModel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid score model: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = matchModelValidate(options.MatchModel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid match model: %v\n", err)
+		os.Exit(1)
+	}
+
+	importedFixtures, err := fixturesLoad(options.FixturesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load fixtures: %v\n", err)
+		os.Exit(1)
+	}
+
+	teamsPath, err := teamsPathResolve(options.TeamsDir, options.Season, options.DatasetsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	teams, err := teamsLoad(teamsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Seed: [%d]\n", options.Seed)
+	fmt.Printf("Dataset: [%s]\n", datasetDescription(teamsPath, options.TeamsDir, options.Season))
+
+	predictions, err := backtestRun(teams, importedFixtures, options.MatchModel, scoreModel, options.NumSimulations, util.NewRng(options.Seed))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: [%s]\n", err.Error())
+		os.Exit(1)
+	}
+	if len(predictions) == 0 {
+		fmt.Fprintf(os.Stderr, "No played fixtures to predict in [%s]\n", options.FixturesFile)
+		os.Exit(1)
+	}
+
+	printBacktestReport(predictions, options.NumSimulations)
+}
+
+func backtestRun(teams []*Team, importedFixtures []ImportedFixture, matchModel string, scoreModel ScoreModel, numSimulations int, rng *util.Rng) ([]BacktestPrediction, error) {
+	lastRound := 0
+	for _, importedFixture := range importedFixtures {
+		lastRound = max(lastRound, importedFixture.Round)
+	}
+
+	predictions := []BacktestPrediction{}
+
+	for knownRound := 0; knownRound < lastRound; knownRound++ {
+		// Results of the rounds after the known one are hidden from the model
+		knownFixtures := make([]ImportedFixture, len(importedFixtures))
+		copy(knownFixtures, importedFixtures)
+		for i := range knownFixtures {
+			if knownFixtures[i].Round > knownRound {
+				knownFixtures[i].Played = false
+			}
+		}
+
+		// Outcome counts of each hidden fixture, in the order of the imported fixtures
+		outcomeCounts := make([][NUM_OUTCOMES]int, len(importedFixtures))
+
+		for i := 0; i < numSimulations; i++ {
+			season, err := newSeasonFromImportedFixtures(teams, knownFixtures, rng)
+			if err != nil {
+				return nil, err
+			}
+			err = season.setModels(matchModel, scoreModel)
+			if err != nil {
+				return nil, err
+			}
+
+			err = season.playAllFixtures()
+			if err != nil {
+				return nil, err
+			}
+
+			// Fixtures of each round keep the order of the imported file
+			fixtureIdxs := make(map[int]int)
+			for j, knownFixture := range knownFixtures {
+				fixture := season.schedule.rounds[knownFixture.Round-1].fixtures[fixtureIdxs[knownFixture.Round]]
+				fixtureIdxs[knownFixture.Round] += 1
+				if knownFixture.Played {
+					continue
+				}
+				outcomeCounts[j][fixtureOutcome(fixture.homeTeamScore, fixture.awayTeamScore)] += 1
+			}
+		}
+
+		for j, importedFixture := range importedFixtures {
+			if knownFixtures[j].Played || !importedFixture.Played {
+				continue
+			}
+
+			prediction := BacktestPrediction{
+				Outcome: fixtureOutcome(importedFixture.HomeTeamScore, importedFixture.AwayTeamScore),
+				Horizon: importedFixture.Round - knownRound,
+			}
+			for outcome, count := range outcomeCounts[j] {
+				prediction.Probabilities[outcome] = (float64(count) + BACKTEST_PSEUDO_COUNT) / (float64(numSimulations) + NUM_OUTCOMES*BACKTEST_PSEUDO_COUNT)
+			}
+			predictions = append(predictions, prediction)
+		}
+	}
+
+	return predictions, nil
+}
+
+func fixtureOutcome(homeScore int, awayScore int) int {
+	if homeScore > awayScore {
+		return OUTCOME_HOME_WIN
+	} else if homeScore < awayScore {
+		return OUTCOME_AWAY_WIN
+	}
+	return OUTCOME_DRAW
+}
+
+// Sum of the squared differences between the predicted probabilities and the outcome (0 is perfect, 2 is the worst)
+func (p *BacktestPrediction) brier() float64 {
+	brier := 0.0
+	for outcome, probability := range p.Probabilities {
+		observed := 0.0
+		if outcome == p.Outcome {
+			observed = 1
+		}
+		brier += (probability - observed) * (probability - observed)
+	}
+	return brier
+}
+
+// Ranked probability score: like the Brier score, but over the cumulative probabilities, as the outcomes are ordered.
+// Predicting a draw when the home team wins is less wrong than predicting an away win.
+func (p *BacktestPrediction) rps() float64 {
+	rps := 0.0
+	cumulativeProbability := 0.0
+	cumulativeObserved := 0.0
+	for outcome := 0; outcome < NUM_OUTCOMES-1; outcome++ {
+		cumulativeProbability += p.Probabilities[outcome]
+		if outcome == p.Outcome {
+			cumulativeObserved = 1
+		}
+		rps += (cumulativeProbability - cumulativeObserved) * (cumulativeProbability - cumulativeObserved)
+	}
+	return rps / (NUM_OUTCOMES - 1)
+}
+
+func (p *BacktestPrediction) logLoss() float64 {
+	return -math.Log(p.Probabilities[p.Outcome])
+}
+
+func backtestScoresCompute(predictions []BacktestPrediction) BacktestScores {
+	scores := BacktestScores{Predictions: len(predictions)}
+	for _, prediction := range predictions {
+		scores.Brier += prediction.brier()
+		scores.Rps += prediction.rps()
+		scores.LogLoss += prediction.logLoss()
+	}
+	if len(predictions) > 0 {
+		scores.Brier /= float64(len(predictions))
+		scores.Rps /= float64(len(predictions))
+		scores.LogLoss /= float64(len(predictions))
+	}
+	return scores
+}
+
+// The same predictions with equal probabilities for all outcomes, to tell whether the model is better than guessing
+func uniformPredictions(predictions []BacktestPrediction) []BacktestPrediction {
+	uniform := make([]BacktestPrediction, 0, len(predictions))
+	for _, prediction := range predictions {
+		prediction.Probabilities = [NUM_OUTCOMES]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+		uniform = append(uniform, prediction)
+	}
+	return uniform
+}
+
+func printBacktestReport(predictions []BacktestPrediction, numSimulations int) {
+	fmt.Printf("Backtest of [%d] predictions, each from %d simulations\n\n", len(predictions), numSimulations)
+
+	format := "%-24s %-12s %-10s %-10s %-10s\n"
+	fmt.Printf(format, "Predictions", "Count", "Brier", "RPS", "LogLoss")
+	printScores := func(name string, scores BacktestScores) {
+		fmt.Printf(format, name, fmt.Sprint(scores.Predictions), fmt.Sprintf("%.4f", scores.Brier), fmt.Sprintf("%.4f", scores.Rps), fmt.Sprintf("%.4f", scores.LogLoss))
+	}
+
+	printScores("All", backtestScoresCompute(predictions))
+	printScores("Next round", backtestScoresCompute(filterPredictionsByHorizon(predictions, 1)))
+	printScores("Uniform (1/3 each)", backtestScoresCompute(uniformPredictions(predictions)))
+
+	for outcome := 0; outcome < NUM_OUTCOMES; outcome++ {
+		fmt.Println()
+		printCalibration(predictions, outcome)
+	}
+}
+
+func filterPredictionsByHorizon(predictions []BacktestPrediction, horizon int) []BacktestPrediction {
+	filtered := []BacktestPrediction{}
+	for _, prediction := range predictions {
+		if prediction.Horizon == horizon {
+			filtered = append(filtered, prediction)
+		}
+	}
+	return filtered
+}
+
+// Groups the predictions by the probability of the outcome, e.g. predicted 60-70% home win, observed 64.2%
+func printCalibration(predictions []BacktestPrediction, outcome int) {
+	numBuckets := int(math.Round(1 / BACKTEST_BUCKET_WIDTH))
+	counts := make([]int, numBuckets)
+	probabilitySums := make([]float64, numBuckets)
+	observed := make([]int, numBuckets)
+
+	for _, prediction := range predictions {
+		probability := prediction.Probabilities[outcome]
+		bucket := min(int(probability/BACKTEST_BUCKET_WIDTH), numBuckets-1)
+		counts[bucket] += 1
+		probabilitySums[bucket] += probability
+		if prediction.Outcome == outcome {
+			observed[bucket] += 1
+		}
+	}
+
+	format := "%-24s %-12s %-14s %-10s\n"
+	fmt.Printf(format, "Predicted "+outcomeNames[outcome], "Count", "MeanPredicted", "Observed")
+	for bucket := 0; bucket < numBuckets; bucket++ {
+		if counts[bucket] == 0 {
+			continue
+		}
+		fmt.Printf(format, fmt.Sprintf("%.0f-%.0f%%", 100*float64(bucket)*BACKTEST_BUCKET_WIDTH, 100*float64(bucket+1)*BACKTEST_BUCKET_WIDTH),
+			fmt.Sprint(counts[bucket]), fmt.Sprintf("%.1f%%", 100*probabilitySums[bucket]/float64(counts[bucket])),
+			fmt.Sprintf("%.1f%%", 100*float64(observed[bucket])/float64(counts[bucket])))
+	}
+}
@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFixtureOutcome(t *testing.T) {
+	tests := []struct {
+		homeScore int
+		awayScore int
+		expected  int
+	}{
+		{2, 1, OUTCOME_HOME_WIN},
+		{0, 0, OUTCOME_DRAW},
+		{3, 3, OUTCOME_DRAW},
+		{0, 1, OUTCOME_AWAY_WIN},
+	}
+
+	for _, test := range tests {
+		if outcome := fixtureOutcome(test.homeScore, test.awayScore); outcome != test.expected {
+			t.Errorf("%d x %d: outcome [%s], expected [%s]", test.homeScore, test.awayScore, outcomeNames[outcome], outcomeNames[test.expected])
+		}
+	}
+}
+
+func TestBacktestPredictionScores(t *testing.T) {
+	tests := []struct {
+		name          string
+		probabilities [NUM_OUTCOMES]float64
+		outcome       int
+		brier         float64
+		rps           float64
+		logLoss       float64
+	}{
+		{"perfect", [NUM_OUTCOMES]float64{1, 0, 0}, OUTCOME_HOME_WIN, 0, 0, 0},
+		{"uniform", [NUM_OUTCOMES]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, OUTCOME_HOME_WIN, 2.0 / 3, 5.0 / 18, math.Log(3)},
+		{"draw", [NUM_OUTCOMES]float64{0.5, 0.3, 0.2}, OUTCOME_DRAW, 0.78, 0.145, -math.Log(0.3)},
+		// An away win predicted for a home win is further off than a draw, which only the RPS sees
+		{"worst", [NUM_OUTCOMES]float64{0, 0, 1}, OUTCOME_HOME_WIN, 2, 1, math.Inf(1)},
+		{"near miss", [NUM_OUTCOMES]float64{0, 1, 0}, OUTCOME_HOME_WIN, 2, 0.5, math.Inf(1)},
+	}
+
+	for _, test := range tests {
+		prediction := BacktestPrediction{Probabilities: test.probabilities, Outcome: test.outcome}
+		if brier := prediction.brier(); math.Abs(brier-test.brier) > 1e-9 {
+			t.Errorf("%s: brier is %.4f, expected %.4f", test.name, brier, test.brier)
+		}
+		if rps := prediction.rps(); math.Abs(rps-test.rps) > 1e-9 {
+			t.Errorf("%s: rps is %.4f, expected %.4f", test.name, rps, test.rps)
+		}
+		if logLoss := prediction.logLoss(); logLoss != test.logLoss && math.Abs(logLoss-test.logLoss) > 1e-9 {
+			t.Errorf("%s: log-loss is %.4f, expected %.4f", test.name, logLoss, test.logLoss)
+		}
+	}
+}
+
+func TestBacktestScoresCompute(t *testing.T) {
+	predictions := []BacktestPrediction{
+		{Probabilities: [NUM_OUTCOMES]float64{1, 0, 0}, Outcome: OUTCOME_HOME_WIN},
+		{Probabilities: [NUM_OUTCOMES]float64{0.5, 0.3, 0.2}, Outcome: OUTCOME_DRAW},
+	}
+
+	scores := backtestScoresCompute(predictions)
+	if scores.Predictions != 2 {
+		t.Errorf("%d predictions, expected 2", scores.Predictions)
+	}
+	if math.Abs(scores.Brier-0.39) > 1e-9 || math.Abs(scores.Rps-0.0725) > 1e-9 || math.Abs(scores.LogLoss+math.Log(0.3)/2) > 1e-9 {
+		t.Errorf("scores are %+v, expected the average of the predictions", scores)
+	}
+
+	if scores := backtestScoresCompute(nil); scores != (BacktestScores{}) {
+		t.Errorf("scores without predictions are %+v, expected zero", scores)
+	}
+}
@@ -0,0 +1,329 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	CALENDAR_DATE_LAYOUT = "2006-01-02"
+	// Kickoff of the matches of other competitions, played in midweek between two league rounds
+	MIDWEEK_KICKOFF_HOUR   = 21
+	MIDWEEK_KICKOFF_MINUTE = 30
+	// Kickoff of the weekend matches of competitions that play twice a week
+	SUNDAY_KICKOFF_HOUR = 16
+)
+
+// A period without matches, such as a FIFA date or the Copa America
+type CalendarBreak struct {
+	Name      string
+	StartDate string
+	EndDate   string
+}
+
+// Dates of the season, loaded from a JSON file (see calendar.json).
+// All weekends between StartDate and EndDate are used, and midweek rounds are added when there are not enough of them.
+type Calendar struct {
+	StartDate string
+	EndDate   string
+	// First match day of the state championships, which are played before the league
+	StateChampionshipsStartDate string
+	Breaks                      []CalendarBreak
+}
+
+// Based on the 2024 Brasileirao
+var defaultCalendar = Calendar{
+	StartDate:                   "2024-04-13",
+	EndDate:                     "2024-12-08",
+	StateChampionshipsStartDate: "2024-01-17",
+	Breaks: []CalendarBreak{
+		{"FIFA date", "2024-06-03", "2024-06-11"},
+		{"Copa America", "2024-06-20", "2024-07-14"},
+		{"FIFA date", "2024-09-02", "2024-09-10"},
+		{"FIFA date", "2024-10-07", "2024-10-15"},
+		{"FIFA date", "2024-11-11", "2024-11-19"},
+	},
+}
+
+// Kickoff of a match, relative to the first day of its round
+type KickoffSlot struct {
+	dayOffset int
+	hour      int
+	minute    int
+}
+
+// Weekend rounds start on Saturday and may have a match on Monday
+var weekendKickoffSlots = []KickoffSlot{
+	{0, 16, 0}, {0, 18, 30}, {0, 21, 0}, {1, 11, 0}, {1, 16, 0},
+	{1, 16, 0}, {1, 18, 30}, {1, 18, 30}, {1, 20, 0}, {2, 20, 0},
+}
+
+// Midweek rounds start on Wednesday
+var midweekKickoffSlots = []KickoffSlot{
+	{0, 19, 0}, {0, 19, 0}, {0, 21, 30}, {0, 21, 30}, {0, 21, 30},
+	{1, 19, 0}, {1, 19, 0}, {1, 20, 0}, {1, 21, 30}, {1, 21, 30},
+}
+
+// Loads the calendar from a JSON file. If calendarPath is empty, the default calendar is returned.
+func calendarLoad(calendarPath string) (Calendar, error) {
+	if calendarPath == "" {
+		return defaultCalendar, nil
+	}
+
+	raw, err := util.ReadFile(calendarPath)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	var calendar Calendar
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&calendar)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("unable to parse calendar [%s]: %v", calendarPath, err)
+	}
+
+	err = calendar.validate()
+	if err != nil {
+		return Calendar{}, fmt.Errorf("invalid calendar [%s]: %v", calendarPath, err)
+	}
+
+	return calendar, nil
+}
+
+func (c *Calendar) validate() error {
+	startDate, err := calendarParseDate(c.StartDate)
+	if err != nil {
+		return err
+	}
+	endDate, err := calendarParseDate(c.EndDate)
+	if err != nil {
+		return err
+	}
+	if endDate.Before(startDate) {
+		return fmt.Errorf("the season ends [%s] before it starts [%s]", c.EndDate, c.StartDate)
+	}
+
+	_, err = calendarParseDate(c.StateChampionshipsStartDate)
+	if err != nil {
+		return err
+	}
+
+	for _, calendarBreak := range c.Breaks {
+		breakStartDate, err := calendarParseDate(calendarBreak.StartDate)
+		if err != nil {
+			return err
+		}
+		breakEndDate, err := calendarParseDate(calendarBreak.EndDate)
+		if err != nil {
+			return err
+		}
+		if breakEndDate.Before(breakStartDate) {
+			return fmt.Errorf("break [%s] ends before it starts", calendarBreak.Name)
+		}
+	}
+
+	return nil
+}
+
+func calendarParseDate(date string) (time.Time, error) {
+	parsed, err := time.Parse(CALENDAR_DATE_LAYOUT, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date [%s], expected YYYY-MM-DD", date)
+	}
+	return parsed, nil
+}
+
+func (c *Calendar) isInBreak(day time.Time) bool {
+	for _, calendarBreak := range c.Breaks {
+		breakStartDate, _ := calendarParseDate(calendarBreak.StartDate)
+		breakEndDate, _ := calendarParseDate(calendarBreak.EndDate)
+		if !day.Before(breakStartDate) && !day.After(breakEndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether all days of a round starting on the received day are free
+func (c *Calendar) isAvailable(firstDay time.Time, numDays int, endDate time.Time) bool {
+	for i := 0; i < numDays; i++ {
+		day := firstDay.AddDate(0, 0, i)
+		if day.After(endDate) || c.isInBreak(day) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the first day of each round. All available weekends are used first,
+// and the missing rounds are played in midweeks spread evenly over the season.
+func (c *Calendar) roundDates(numRounds int) ([]time.Time, error) {
+	startDate, err := calendarParseDate(c.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := calendarParseDate(c.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	weekends := []time.Time{}
+	midweeks := []time.Time{}
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday && c.isAvailable(day, 3, endDate) {
+			weekends = append(weekends, day)
+		} else if day.Weekday() == time.Wednesday && c.isAvailable(day, 2, endDate) {
+			midweeks = append(midweeks, day)
+		}
+	}
+
+	if len(weekends)+len(midweeks) < numRounds {
+		return nil, fmt.Errorf("the calendar has %d available dates, but the season has %d rounds", len(weekends)+len(midweeks), numRounds)
+	}
+
+	if len(weekends) >= numRounds {
+		return weekends[:numRounds], nil
+	}
+
+	numMidweeks := numRounds - len(weekends)
+	dates := weekends
+	for i := 0; i < numMidweeks; i++ {
+		dates = append(dates, midweeks[(2*i+1)*len(midweeks)/(2*numMidweeks)])
+	}
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+	return dates, nil
+}
+
+// Kickoffs of the fixtures of a round starting on the received day
+func roundKickoffs(firstDay time.Time, numFixtures int) []time.Time {
+	slots := weekendKickoffSlots
+	if firstDay.Weekday() != time.Saturday {
+		slots = midweekKickoffSlots
+	}
+
+	kickoffs := make([]time.Time, 0, numFixtures)
+	for i := 0; i < numFixtures; i++ {
+		slot := slots[i%len(slots)]
+		kickoffs = append(kickoffs, firstDay.AddDate(0, 0, slot.dayOffset).Add(time.Duration(slot.hour)*time.Hour+time.Duration(slot.minute)*time.Minute))
+	}
+	return kickoffs
+}
+
+// Kickoff of the next matchday of a competition that plays twice a week, on Wednesdays and Sundays
+func nextMatchdayKickoff(kickoff time.Time) time.Time {
+	if kickoff.IsZero() {
+		return kickoff
+	}
+	day := kickoff.Truncate(24 * time.Hour)
+	if day.Weekday() == time.Wednesday {
+		return day.AddDate(0, 0, 4).Add(SUNDAY_KICKOFF_HOUR * time.Hour)
+	}
+	return midweekKickoff(day.AddDate(0, 0, (int(time.Wednesday)-int(day.Weekday())+7)%7))
+}
+
+func midweekKickoff(day time.Time) time.Time {
+	return day.Truncate(24 * time.Hour).Add(MIDWEEK_KICKOFF_HOUR*time.Hour + MIDWEEK_KICKOFF_MINUTE*time.Minute)
+}
+
+// Sets the date of each round and the kickoff of each fixture, following the calendar
+func (s *Schedule) assignDates(calendar Calendar) error {
+	dates, err := calendar.roundDates(len(s.rounds))
+	if err != nil {
+		return err
+	}
+
+	s.setDates(dates)
+	return nil
+}
+
+// Like assignDates, but leaves the rounds undated when the calendar doesn't have enough dates.
+// Used for the default calendar, which must not limit the size of the league (e.g. 26 teams play 50 rounds).
+func (s *Schedule) assignDatesIfAvailable(calendar Calendar) {
+	dates, err := calendar.roundDates(len(s.rounds))
+	if err != nil {
+		return
+	}
+
+	s.setDates(dates)
+}
+
+func (s *Schedule) setDates(dates []time.Time) {
+	for i, round := range s.rounds {
+		round.date = dates[i]
+		for j, kickoff := range roundKickoffs(dates[i], len(round.fixtures)) {
+			round.fixtures[j].kickoff = kickoff
+		}
+	}
+}
+
+// Kickoff of the matches of other competitions played after the received league round, on the first Wednesday
+// after it that is not in a break. Returns false if the next league round is played before the end of that week.
+func (s *Season) midweekKickoffAfterRound(roundIdx int) (time.Time, bool) {
+	round := s.schedule.rounds[roundIdx]
+	if round.date.IsZero() {
+		return time.Time{}, true
+	}
+
+	lastDay := round.date
+	for _, fixture := range round.fixtures {
+		if fixture.kickoff.After(lastDay) {
+			lastDay = fixture.kickoff.Truncate(24 * time.Hour)
+		}
+	}
+
+	wednesday := lastDay.AddDate(0, 0, 1)
+	for wednesday.Weekday() != time.Wednesday || s.calendar.isInBreak(wednesday) {
+		wednesday = wednesday.AddDate(0, 0, 1)
+	}
+
+	if roundIdx+1 < len(s.schedule.rounds) && !s.schedule.rounds[roundIdx+1].date.After(wednesday.AddDate(0, 0, 2)) {
+		return time.Time{}, false
+	}
+	return midweekKickoff(wednesday), true
+}
+
+// Adds days to a kickoff, keeping unknown (zero) kickoffs unknown
+func addDays(kickoff time.Time, days int) time.Time {
+	if kickoff.IsZero() {
+		return kickoff
+	}
+	return kickoff.AddDate(0, 0, days)
+}
+
+// Header of a matchday of a competition other than the league, e.g. Copa do Brasil - Final (leg 1) - Wed 17/04 21:30
+func formatMatchdayDescription(competition string, matchday string, kickoff time.Time) string {
+	if kickoff.IsZero() {
+		return fmt.Sprintf("%s - %s", competition, matchday)
+	}
+	return fmt.Sprintf("%s - %s - %s", competition, matchday, formatKickoff(kickoff))
+}
+
+// Kickoff in a machine-friendly format, e.g. 2024-04-13 16:00. Empty if the kickoff is unknown.
+func formatIsoKickoff(kickoff time.Time) string {
+	if kickoff.IsZero() {
+		return ""
+	}
+	return kickoff.Format(CALENDAR_DATE_LAYOUT + " 15:04")
+}
+
+// Day of the week and date, e.g. Sat 13/04/2024
+func formatDate(date time.Time) string {
+	return date.Format("Mon 02/01/2006")
+}
+
+// Day of the week, date and time, e.g. Sat 13/04 16:00. Empty if the kickoff is unknown.
+func formatKickoff(kickoff time.Time) string {
+	if kickoff.IsZero() {
+		return ""
+	}
+	return kickoff.Format("Mon 02/01 15:04")
+}
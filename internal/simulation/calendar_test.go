@@ -0,0 +1,104 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestRoundDatesFollowTheCalendar(t *testing.T) {
+	calendar := defaultCalendar
+	dates, err := calendar.roundDates(38)
+	if err != nil {
+		t.Fatalf("unable to get the round dates: %v", err)
+	}
+	if len(dates) != 38 {
+		t.Fatalf("%d dates, expected 38", len(dates))
+	}
+
+	startDate, _ := calendarParseDate(calendar.StartDate)
+	endDate, _ := calendarParseDate(calendar.EndDate)
+	if !dates[0].Equal(startDate) {
+		t.Errorf("first round on %s, expected %s", formatDate(dates[0]), formatDate(startDate))
+	}
+
+	midweeks := 0
+	for i, date := range dates {
+		if date.Before(startDate) || date.After(endDate) {
+			t.Errorf("round %d on %s, outside of the season", i+1, formatDate(date))
+		}
+		if calendar.isInBreak(date) {
+			t.Errorf("round %d on %s, during a break", i+1, formatDate(date))
+		}
+		if i > 0 && !date.After(dates[i-1]) {
+			t.Errorf("round %d on %s, not after round %d on %s", i+1, formatDate(date), i, formatDate(dates[i-1]))
+		}
+		if date.Weekday() == time.Wednesday {
+			midweeks += 1
+		} else if date.Weekday() != time.Saturday {
+			t.Errorf("round %d starts on %s, expected a Saturday or a Wednesday", i+1, date.Weekday())
+		}
+	}
+	if midweeks == 0 {
+		t.Errorf("no midweek rounds, but the default calendar doesn't have 38 free weekends")
+	}
+
+	if _, err := calendar.roundDates(58); err == nil {
+		t.Errorf("58 rounds fit in the default calendar")
+	}
+}
+
+func TestRoundKickoffs(t *testing.T) {
+	saturday, _ := calendarParseDate("2024-04-13")
+	kickoffs := roundKickoffs(saturday, 10)
+	if len(kickoffs) != 10 {
+		t.Fatalf("%d kickoffs, expected 10", len(kickoffs))
+	}
+	for i, kickoff := range kickoffs {
+		if kickoff.Before(saturday) || kickoff.After(saturday.AddDate(0, 0, 3)) {
+			t.Errorf("match %d kicks off on %s, outside of the weekend", i+1, formatKickoff(kickoff))
+		}
+	}
+	if expected := saturday.Add(16 * time.Hour); !kickoffs[0].Equal(expected) {
+		t.Errorf("first match kicks off on %s, expected %s", formatKickoff(kickoffs[0]), formatKickoff(expected))
+	}
+}
+
+func TestSeasonDates(t *testing.T) {
+	season := playTestSeason(t, loadTestTeams(t), 1)
+	for i, round := range season.schedule.rounds {
+		if round.date.IsZero() {
+			t.Fatalf("round %d has no date", i+1)
+		}
+		for _, fixture := range round.fixtures {
+			if fixture.kickoff.Before(round.date) {
+				t.Errorf("round %d: %s x %s kicks off on %s, before the round", i+1, fixture.homeTeam, fixture.awayTeam, formatKickoff(fixture.kickoff))
+			}
+		}
+	}
+}
+
+// Leagues with more rounds than the default calendar has dates are played without dates
+func TestSeasonWithMoreRoundsThanDatesIsUndated(t *testing.T) {
+	teams := []*Team{}
+	for i := 1; i <= 26; i++ {
+		teams = append(teams, &Team{Name: fmt.Sprintf("Club %d", i), Attack: 5, Midfield: 5, Defense: 5, HomeFactor: 5})
+	}
+
+	season, err := newSeason(teams, util.NewRng(1))
+	if err != nil {
+		t.Fatalf("unable to create a season with 26 teams: %v", err)
+	}
+	for i, round := range season.schedule.rounds {
+		if !round.date.IsZero() {
+			t.Errorf("round %d is dated %s", i+1, formatDate(round.date))
+		}
+	}
+
+	err = season.playAllFixtures()
+	if err != nil {
+		t.Fatalf("unable to play the season: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Average number of cards shown to each team in a match, close to the Brasileirao averages
+	YELLOW_CARDS_PER_TEAM_PER_MATCH = 2.4
+	RED_CARDS_PER_TEAM_PER_MATCH    = 0.1
+	// Accumulated yellow cards that suspend a player for the next match
+	YELLOW_CARDS_FOR_SUSPENSION = 3
+	// Matches a player misses after a red card
+	RED_CARD_SUSPENSION_MATCHES = 1
+	// Strength lost by teams without a squad for each suspended player
+	SUSPENDED_PLAYER_STRENGTH_PENALTY = 0.03
+	// Number of generic players of teams without a squad, to whom cards are given
+	GENERIC_PLAYERS_PER_TEAM = 11
+)
+
+type Card struct {
+	team   string
+	player string
+	red    bool
+}
+
+// Name of a generic player of a team without a squad, e.g. Player 7 of Bahia
+func genericPlayerName(teamName string, playerIdx int) string {
+	return fmt.Sprintf("Player %d of %s", playerIdx+1, teamName)
+}
+
+// Draws the cards shown to a team in a match, each one to a random player of its starting XI
+func generateCards(team *Team, lineup *Lineup, rng *util.Rng) []Card {
+	cards := []Card{}
+
+	randomPlayer := func() string {
+		if len(lineup.players) == 0 {
+			return genericPlayerName(team.Name, util.RandomInt(rng, GENERIC_PLAYERS_PER_TEAM))
+		}
+		return lineup.players[util.RandomInt(rng, len(lineup.players))].Name
+	}
+
+	numYellowCards := util.PoissonKnuth(rng, YELLOW_CARDS_PER_TEAM_PER_MATCH)
+	for i := 0; i < numYellowCards; i++ {
+		cards = append(cards, Card{team: team.Name, player: randomPlayer()})
+	}
+
+	numRedCards := util.PoissonKnuth(rng, RED_CARDS_PER_TEAM_PER_MATCH)
+	for i := 0; i < numRedCards; i++ {
+		cards = append(cards, Card{team: team.Name, player: randomPlayer(), red: true})
+	}
+
+	return cards
+}
+
+// Number of yellow and red cards shown to the team in the fixture
+func (f *Fixture) cardsOf(teamName string) (int, int) {
+	yellowCards := 0
+	redCards := 0
+	for _, card := range f.cards {
+		if card.team != teamName {
+			continue
+		}
+		if card.red {
+			redCards += 1
+		} else {
+			yellowCards += 1
+		}
+	}
+	return yellowCards, redCards
+}
+
+// Serves the suspensions of the players that missed the fixture, then books the cards the team received in it.
+// Suspensions are served in the next match of the team, whatever the competition.
+func (t *Team) updateDiscipline(playedFixture *Fixture) {
+	for player, matches := range t.DynamicAttributes.Suspensions {
+		if matches <= 1 {
+			delete(t.DynamicAttributes.Suspensions, player)
+		} else {
+			t.DynamicAttributes.Suspensions[player] = matches - 1
+		}
+	}
+
+	for _, card := range playedFixture.cards {
+		if card.team != t.Name {
+			continue
+		}
+
+		if card.red {
+			t.DynamicAttributes.Suspensions[card.player] += RED_CARD_SUSPENSION_MATCHES
+			continue
+		}
+
+		t.DynamicAttributes.YellowCards[card.player] += 1
+		if t.DynamicAttributes.YellowCards[card.player] == YELLOW_CARDS_FOR_SUSPENSION {
+			delete(t.DynamicAttributes.YellowCards, card.player)
+			t.DynamicAttributes.Suspensions[card.player] += 1
+		}
+	}
+}
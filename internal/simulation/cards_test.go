@@ -0,0 +1,167 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func newTestTeamWithSquad() *Team {
+	squad := &Squad{Team: "A"}
+	positions := []struct {
+		position string
+		count    int
+	}{{PLAYER_POSITION_GOALKEEPER, 2}, {PLAYER_POSITION_DEFENDER, 5}, {PLAYER_POSITION_MIDFIELDER, 5}, {PLAYER_POSITION_FORWARD, 3}}
+	for _, position := range positions {
+		for i := 0; i < position.count; i++ {
+			// The first player of each position is the best one
+			squad.Players = append(squad.Players, &Player{Name: fmt.Sprintf("%s %d", position.position, i+1), Position: position.position, Rating: float64(9 - i), Fitness: 10})
+		}
+	}
+
+	team := newTestTeams("A")[0]
+	team.Squad = squad
+	team.resetDynamicAttributes()
+	return team
+}
+
+func newFixtureWithCards(cards ...Card) *Fixture {
+	fixture := newPlayedFixture("A", "B", 0, 0)
+	fixture.cards = cards
+	return fixture
+}
+
+func TestYellowCardsAccumulateToASuspension(t *testing.T) {
+	team := newTestTeamWithSquad()
+	yellowCard := Card{team: "A", player: "FW 1"}
+
+	for i := 1; i < YELLOW_CARDS_FOR_SUSPENSION; i++ {
+		team.updateDiscipline(newFixtureWithCards(yellowCard, Card{team: "B", player: "FW 2"}))
+		if team.DynamicAttributes.YellowCards["FW 1"] != i || team.DynamicAttributes.Suspensions["FW 1"] != 0 {
+			t.Fatalf("after %d yellow cards: %d booked, %d matches suspended", i, team.DynamicAttributes.YellowCards["FW 1"], team.DynamicAttributes.Suspensions["FW 1"])
+		}
+	}
+	if team.DynamicAttributes.YellowCards["FW 2"] != 0 {
+		t.Errorf("a card of the other team was booked")
+	}
+
+	team.updateDiscipline(newFixtureWithCards(yellowCard))
+	if _, ok := team.DynamicAttributes.YellowCards["FW 1"]; ok || team.DynamicAttributes.Suspensions["FW 1"] != 1 {
+		t.Errorf("after %d yellow cards: %d booked, %d matches suspended, expected 0 and 1", YELLOW_CARDS_FOR_SUSPENSION,
+			team.DynamicAttributes.YellowCards["FW 1"], team.DynamicAttributes.Suspensions["FW 1"])
+	}
+
+	lineup, err := team.pickLineup()
+	if err != nil {
+		t.Fatalf("unable to pick lineup: %v", err)
+	}
+	for _, name := range lineup.playerNames() {
+		if name == "FW 1" {
+			t.Errorf("suspended player is in the lineup")
+		}
+	}
+
+	// The suspension is served in the next match
+	team.updateDiscipline(newFixtureWithCards())
+	if _, ok := team.DynamicAttributes.Suspensions["FW 1"]; ok {
+		t.Errorf("suspension was not served")
+	}
+}
+
+func TestRedCardSuspendsForTheNextMatch(t *testing.T) {
+	team := newTestTeamWithSquad()
+	team.updateDiscipline(newFixtureWithCards(Card{team: "A", player: "DF 1", red: true}))
+	if team.DynamicAttributes.Suspensions["DF 1"] != RED_CARD_SUSPENSION_MATCHES {
+		t.Errorf("red card suspends for %d matches, expected %d", team.DynamicAttributes.Suspensions["DF 1"], RED_CARD_SUSPENSION_MATCHES)
+	}
+	if team.DynamicAttributes.YellowCards["DF 1"] != 0 {
+		t.Errorf("red card was booked as a yellow card")
+	}
+
+	team.updateDiscipline(newFixtureWithCards())
+	if len(team.DynamicAttributes.Suspensions) != 0 {
+		t.Errorf("suspensions left after the next match: %v", team.DynamicAttributes.Suspensions)
+	}
+}
+
+func TestSuspensionsWeakenTeamsWithoutSquad(t *testing.T) {
+	team := newTestTeams("A")[0]
+	team.resetDynamicAttributes()
+	fullStrength, err := team.pickLineup()
+	if err != nil {
+		t.Fatalf("unable to pick lineup: %v", err)
+	}
+
+	team.DynamicAttributes.Suspensions[genericPlayerName("A", 0)] = 1
+	team.DynamicAttributes.Suspensions[genericPlayerName("A", 1)] = 1
+	weakened, err := team.pickLineup()
+	if err != nil {
+		t.Fatalf("unable to pick lineup: %v", err)
+	}
+
+	expected := (1 - 2*SUSPENDED_PLAYER_STRENGTH_PENALTY) * fullStrength.attack
+	if math.Abs(weakened.attack-expected) > 1e-9 {
+		t.Errorf("attack with two suspended players is %.3f, expected %.3f", weakened.attack, expected)
+	}
+}
+
+func TestGenerateCards(t *testing.T) {
+	team := newTestTeamWithSquad()
+	lineup, err := team.pickLineup()
+	if err != nil {
+		t.Fatalf("unable to pick lineup: %v", err)
+	}
+	inLineup := make(map[string]bool)
+	for _, name := range lineup.playerNames() {
+		inLineup[name] = true
+	}
+
+	const numMatches = 5000
+	rng := util.NewRng(1)
+	yellowCards := 0
+	redCards := 0
+	for i := 0; i < numMatches; i++ {
+		for _, card := range generateCards(team, lineup, rng) {
+			if card.team != "A" || !inLineup[card.player] {
+				t.Fatalf("card given to [%s] of [%s], who is not in the lineup", card.player, card.team)
+			}
+			if card.red {
+				redCards += 1
+			} else {
+				yellowCards += 1
+			}
+		}
+	}
+
+	if average := float64(yellowCards) / numMatches; math.Abs(average-YELLOW_CARDS_PER_TEAM_PER_MATCH) > 0.1 {
+		t.Errorf("%.2f yellow cards per match, expected %.2f", average, YELLOW_CARDS_PER_TEAM_PER_MATCH)
+	}
+	if average := float64(redCards) / numMatches; math.Abs(average-RED_CARDS_PER_TEAM_PER_MATCH) > 0.02 {
+		t.Errorf("%.3f red cards per match, expected %.3f", average, RED_CARDS_PER_TEAM_PER_MATCH)
+	}
+}
+
+// The cards of the standings are the ones shown in the played fixtures
+func TestStandingsCountCards(t *testing.T) {
+	season := playTestSeason(t, loadTestTeams(t), 1)
+	yellowCards := make(map[string]int)
+	redCards := make(map[string]int)
+	for _, round := range season.schedule.rounds {
+		for _, fixture := range round.fixtures {
+			for _, teamName := range []string{fixture.homeTeam, fixture.awayTeam} {
+				yellow, red := fixture.cardsOf(teamName)
+				yellowCards[teamName] += yellow
+				redCards[teamName] += red
+			}
+		}
+	}
+
+	for _, teamStatistic := range season.standingsGenerate().TeamStatistics {
+		if teamStatistic.YellowCards != yellowCards[teamStatistic.Name] || teamStatistic.RedCards != redCards[teamStatistic.Name] {
+			t.Errorf("%s: %d yellow and %d red cards in the standings, %d and %d in the fixtures", teamStatistic.Name,
+				teamStatistic.YellowCards, teamStatistic.RedCards, yellowCards[teamStatistic.Name], redCards[teamStatistic.Name])
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package simulation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	COPA_DO_BRASIL_NAME = "Copa do Brasil"
+	// All Copa do Brasil ties, including the final, are played over two legs
+	COPA_DO_BRASIL_LEGS = 2
+)
+
+// A knockout cup with two-legged ties, played alongside the league and sharing its teams,
+// so a cup run affects the morale and physical condition of the teams in the league.
+// Each stage is drawn when the previous one finishes.
+type Cup struct {
+	name       string
+	teams      map[string]*Team
+	rng        *util.Rng
+	matchModel MatchModel
+	events     *EventEmitter
+	stages     []*KnockoutStage
+	// Leg of the current stage that will be played next (0 or 1)
+	nextLegIdx int
+	// League round after which each cup leg is played
+	legsSchedule []int
+	playedLegs   int
+	champion     string
+	// Legs played after the last league round, so they can be printed with it
+	lastPlayedLegs []string
+}
+
+func newCup(name string, season *Season) (*Cup, error) {
+	if len(season.teams) < 2 {
+		return nil, fmt.Errorf("%s needs at least two teams", name)
+	}
+
+	cup := Cup{
+		name:       name,
+		teams:      season.teams,
+		rng:        season.rng,
+		matchModel: season.matchModel,
+		events:     season.events,
+	}
+
+	cup.drawStage(season.teamsGetAllNames())
+
+	numLegs := cupNumStages(len(season.teams)) * COPA_DO_BRASIL_LEGS
+	cup.legsSchedule = spreadMatchdaysOverLeague(len(season.schedule.rounds), numLegs)
+
+	return &cup, nil
+}
+
+// Number of stages needed for the received number of teams, including a preliminary round if it is not a power of two
+func cupNumStages(numTeams int) int {
+	numStages := 0
+	for remaining := 1; remaining < numTeams; remaining *= 2 {
+		numStages += 1
+	}
+	return numStages
+}
+
+// Draws the ties of a new stage among the received teams.
+// If the number of teams is not a power of two, it is a preliminary round in which some teams get a bye.
+func (c *Cup) drawStage(teamNames []string) {
+	drawn := make([]string, 0, len(teamNames))
+	for _, i := range c.rng.Perm(len(teamNames)) {
+		drawn = append(drawn, teamNames[i])
+	}
+
+	nextStageSize := 1
+	for nextStageSize*2 <= len(drawn) {
+		nextStageSize *= 2
+	}
+
+	stage := KnockoutStage{name: knockoutStageName(len(drawn)), numLegs: COPA_DO_BRASIL_LEGS}
+	numPlaying := len(drawn)
+	if nextStageSize != len(drawn) {
+		stage.name = "Preliminary round"
+		numPlaying = 2 * (len(drawn) - nextStageSize)
+	}
+
+	for i := 0; i < numPlaying; i += 2 {
+		stage.ties = append(stage.ties, newKnockoutTie(drawn[i], drawn[i+1], COPA_DO_BRASIL_LEGS))
+	}
+	for _, teamName := range drawn[numPlaying:] {
+		stage.ties = append(stage.ties, newKnockoutBye(teamName))
+	}
+
+	c.stages = append(c.stages, &stage)
+	c.nextLegIdx = 0
+}
+
+func (c *Cup) currentStage() *KnockoutStage {
+	return c.stages[len(c.stages)-1]
+}
+
+func (c *Cup) finished() bool {
+	return c.champion != ""
+}
+
+// Plays the next cup leg, at the received kickoff, if it is scheduled after the received league round or before it.
+// Only one leg is played per midweek. When the league is finished, all remaining legs are played, one per week.
+func (c *Cup) playLegsScheduledAfterRound(roundIdx int, leagueFinished bool, kickoff time.Time) error {
+	c.lastPlayedLegs = nil
+
+	for !c.finished() && (leagueFinished || (c.playedLegs < len(c.legsSchedule) && c.legsSchedule[c.playedLegs] <= roundIdx)) {
+		err := c.playNextLeg(kickoff)
+		if err != nil {
+			return err
+		}
+		if !leagueFinished {
+			break
+		}
+		kickoff = addDays(kickoff, 7)
+	}
+
+	return nil
+}
+
+func (c *Cup) playNextLeg(kickoff time.Time) error {
+	stage := c.currentStage()
+	legIdx := c.nextLegIdx
+
+	err := stage.playLeg(legIdx, kickoff, c.teams, c.matchModel, c.rng, c.events, c.name)
+	if err != nil {
+		return err
+	}
+
+	c.lastPlayedLegs = append(c.lastPlayedLegs, formatMatchdayDescription(c.name, fmt.Sprintf("%s (leg %d)", stage.name, legIdx+1), kickoff))
+	c.playedLegs += 1
+	c.nextLegIdx += 1
+
+	if c.nextLegIdx < stage.numLegs {
+		return nil
+	}
+
+	stage.resolve(c.teams, c.rng)
+
+	winners := stage.winners()
+	if len(winners) == 1 {
+		c.champion = winners[0]
+	} else {
+		c.drawStage(winners)
+	}
+
+	return nil
+}
+
+// Prints the legs played after the last league round
+func (c *Cup) printLastPlayedLegs() {
+	for _, legDescription := range c.lastPlayedLegs {
+		fmt.Printf("%s\n", legDescription)
+	}
+	if len(c.lastPlayedLegs) > 0 {
+		c.printCurrentStage()
+	}
+}
+
+func (c *Cup) printCurrentStage() {
+	stage := c.currentStage()
+	// The current stage may have just been drawn, so show the one whose legs were played
+	if c.nextLegIdx == 0 && len(c.stages) > 1 && !c.finished() {
+		stage = c.stages[len(c.stages)-2]
+	}
+	stage.print()
+}
+
+// Prints all stages of the cup, from the first one to the final
+func (c *Cup) printBracket() {
+	fmt.Println()
+	fmt.Printf("%s\n", c.name)
+	for _, stage := range c.stages {
+		stage.print()
+	}
+
+	if c.finished() {
+		fmt.Printf("%s champion: [%s]\n", c.name, c.champion)
+	}
+}
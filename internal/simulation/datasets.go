@@ -0,0 +1,120 @@
+package simulation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// Team files of past and current seasons, in the layout datasets/<season>/<league>/*.json.
+	// Looked up in the working directory, then next to the executable, unless another directory is chosen with -datasets-dir.
+	DATASETS_PATH = "datasets/"
+	// League of the season selected with -season
+	DATASET_LEAGUE = "serie-a"
+	// Season whose teams are loaded when no teams directory or season is chosen
+	DATASET_CURRENT_SEASON = "2024"
+)
+
+// Directory with the datasets: the received one, DATASETS_PATH in the working directory, or DATASETS_PATH next to the executable
+func datasetsPathResolve(datasetsDir string) string {
+	if datasetsDir != "" {
+		return datasetsDir
+	}
+
+	if isDirectory(DATASETS_PATH) {
+		return DATASETS_PATH
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executablePath := filepath.Join(filepath.Dir(executable), DATASETS_PATH)
+		if isDirectory(executablePath) {
+			return executablePath
+		}
+	}
+
+	return DATASETS_PATH
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Resolves a path inside the datasets, e.g. datasets/2024/serie-b, in the directory chosen with -datasets-dir or wherever
+// the datasets are found (see datasetsPathResolve), so it doesn't depend on the working directory. Other paths are kept.
+func datasetPathResolve(path string, datasetsDir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	relativePath, err := filepath.Rel(DATASETS_PATH, path)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join(datasetsPathResolve(datasetsDir), relativePath)
+}
+
+// Directory with the teams of the received season, e.g. datasets/2024/serie-a
+func datasetTeamsPath(datasetsPath string, season string) string {
+	return filepath.Join(datasetsPath, season, DATASET_LEAGUE)
+}
+
+// Directory from which the league teams are loaded: the received teams directory, or the dataset of the received season
+// (DATASET_CURRENT_SEASON if none) in the received datasets directory
+func teamsPathResolve(teamsDir string, season string, datasetsDir string) (string, error) {
+	if teamsDir != "" && season != "" {
+		return "", fmt.Errorf("a teams directory and a season can't be both chosen")
+	}
+
+	if teamsDir != "" {
+		return teamsDir, nil
+	}
+
+	if season == "" {
+		season = DATASET_CURRENT_SEASON
+	}
+
+	datasetsPath := datasetsPathResolve(datasetsDir)
+	teamsPath := datasetTeamsPath(datasetsPath, season)
+	if !isDirectory(teamsPath) {
+		seasons := datasetSeasons(datasetsPath)
+		if len(seasons) == 0 {
+			return "", fmt.Errorf("no datasets in [%s] (choose their directory with -datasets-dir)", datasetsPath)
+		}
+		return "", fmt.Errorf("no dataset for season [%s] in [%s] (available: %s)", season, teamsPath, strings.Join(seasons, ", "))
+	}
+	return teamsPath, nil
+}
+
+// Seasons with a dataset of the league, oldest first
+func datasetSeasons(datasetsPath string) []string {
+	seasons := []string{}
+
+	entries, err := os.ReadDir(datasetsPath)
+	if err != nil {
+		return seasons
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && isDirectory(datasetTeamsPath(datasetsPath, entry.Name())) {
+			seasons = append(seasons, entry.Name())
+		}
+	}
+
+	sort.Strings(seasons)
+	return seasons
+}
+
+// Active dataset, as listed in the output header, e.g. 2024 (datasets/2024/serie-a)
+func datasetDescription(teamsPath string, teamsDir string, season string) string {
+	if teamsDir != "" {
+		return teamsPath
+	}
+	if season == "" {
+		season = DATASET_CURRENT_SEASON
+	}
+	return fmt.Sprintf("%s (%s)", season, teamsPath)
+}
@@ -0,0 +1,40 @@
+package simulation
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestDatasetPathResolve(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"datasets/2024/serie-b/", filepath.Join("/opt/datasets", "2024", "serie-b")},
+		{"datasets/2024/state-championships/paulistao.json", filepath.Join("/opt/datasets", "2024", "state-championships", "paulistao.json")},
+		{"teams-serie-b/", "teams-serie-b/"},
+		{"../datasets/2024/serie-b", "../datasets/2024/serie-b"},
+		{"/srv/datasets/2024/serie-b", "/srv/datasets/2024/serie-b"},
+	}
+
+	for _, test := range tests {
+		if resolved := datasetPathResolve(test.path, "/opt/datasets"); resolved != test.expected {
+			t.Errorf("[%s] resolved to [%s], expected [%s]", test.path, resolved, test.expected)
+		}
+	}
+}
+
+func TestStateChampionshipTeamsAreFoundInTheDatasets(t *testing.T) {
+	season, err := newSeason(loadTestTeams(t), util.NewRng(1))
+	if err != nil {
+		t.Fatalf("unable to create season: %v", err)
+	}
+	datasetsDir := filepath.Join("..", "..", DATASETS_PATH)
+	formatPath := datasetPathResolve("datasets/2024/state-championships/paulistao.json", datasetsDir)
+
+	if _, err := newStateChampionship(formatPath, season, datasetsDir); err != nil {
+		t.Errorf("unable to create the state championship: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/bit101/go-ansi"
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	MATCH_MODEL_ELO = "elo"
+
+	// Rating of a team whose attributes are all 5
+	ELO_INITIAL_RATING = 1500.0
+	// Rating points of each point of the average of Attack, Midfield and Defense
+	ELO_POINTS_PER_ATTRIBUTE = 60.0
+	// Rating points added to the home team for each point of its HomeFactor
+	ELO_HOME_ADVANTAGE_PER_HOME_FACTOR = 12.0
+	// How fast ratings react to results
+	ELO_K_FACTOR = 30.0
+	// Expected goals of each team in a match between teams of the same rating
+	ELO_GOALS_PER_TEAM = 1.3
+	// Rating difference that multiplies the expected goals of the strongest team (and divides those of the weakest) by 10
+	ELO_GOALS_RATING_SCALE = 1600.0
+)
+
+// Ratings of the teams at the start of the season, keyed by team name
+type EloRatings map[string]float64
+
+// Scores drawn from the expected goals implied by the Elo ratings of both teams
+type EloMatchModel struct {
+	scoreModel ScoreModel
+}
+
+// Rating implied by the static attributes, used when no ratings file is given
+func (t *Team) eloRatingFromAttributes() float64 {
+	return ELO_INITIAL_RATING + ELO_POINTS_PER_ATTRIBUTE*(t.rating()/3-5)
+}
+
+func (t *Team) eloHomeAdvantage() float64 {
+	return ELO_HOME_ADVANTAGE_PER_HOME_FACTOR * t.HomeFactor
+}
+
+// Loads the Elo ratings from a JSON file with an object mapping team names to ratings
+func eloRatingsLoad(ratingsPath string) (EloRatings, error) {
+	raw, err := util.ReadFile(ratingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ratings EloRatings
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	err = decoder.Decode(&ratings)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse Elo ratings [%s]: %v", ratingsPath, err)
+	}
+
+	for teamName, rating := range ratings {
+		if rating <= 0 {
+			return nil, fmt.Errorf("invalid Elo ratings [%s]: team [%s] has non-positive rating [%.1f]", ratingsPath, teamName, rating)
+		}
+	}
+
+	return ratings, nil
+}
+
+// Replaces the ratings seeded from the attributes. Teams missing from the file keep them.
+func (r EloRatings) apply(teams map[string]*Team) error {
+	for teamName, rating := range r {
+		team, ok := teams[teamName]
+		if !ok {
+			return fmt.Errorf("Elo rating of unknown team [%s]", teamName)
+		}
+		team.DynamicAttributes.Elo = rating
+	}
+	return nil
+}
+
+// Difference between the ratings of both teams, including the home advantage
+func eloRatingDifference(homeTeam *Team, awayTeam *Team, neutralVenue bool) float64 {
+	difference := homeTeam.DynamicAttributes.Elo - awayTeam.DynamicAttributes.Elo
+	if !neutralVenue {
+		difference += homeTeam.eloHomeAdvantage()
+	}
+	return difference
+}
+
+// Expected share of the points of the home team, from 0 (certain defeat) to 1 (certain win)
+func eloExpectedResult(ratingDifference float64) float64 {
+	return 1 / (1 + math.Pow(10, -ratingDifference/400))
+}
+
+// Bigger wins move the ratings more, as in the World Football Elo Ratings
+func eloGoalMarginMultiplier(goalMargin int) float64 {
+	goalMargin = util.IntAbs(goalMargin)
+	switch {
+	case goalMargin <= 1:
+		return 1
+	case goalMargin == 2:
+		return 1.5
+	}
+	return (11 + float64(goalMargin)) / 8
+}
+
+// Moves the ratings of both teams towards the result of the played fixture. Rating points are exchanged, so their sum is kept.
+func updateEloRatings(homeTeam *Team, awayTeam *Team, playedFixture *Fixture, neutralVenue bool) {
+	result := 0.5
+	if playedFixture.homeTeamScore > playedFixture.awayTeamScore {
+		result = 1
+	} else if playedFixture.homeTeamScore < playedFixture.awayTeamScore {
+		result = 0
+	}
+
+	expected := eloExpectedResult(eloRatingDifference(homeTeam, awayTeam, neutralVenue))
+	change := ELO_K_FACTOR * eloGoalMarginMultiplier(playedFixture.homeTeamScore-playedFixture.awayTeamScore) * (result - expected)
+
+	homeTeam.DynamicAttributes.Elo += change
+	awayTeam.DynamicAttributes.Elo -= change
+}
+
+func (m EloMatchModel) Name() string {
+	return MATCH_MODEL_ELO
+}
+
+func (m EloMatchModel) playMatch(input MatchInput, rng *util.Rng) (MatchOutcome, error) {
+	difference := eloRatingDifference(input.HomeTeam, input.AwayTeam, input.NeutralVenue)
+
+	homeLambda := ELO_GOALS_PER_TEAM * math.Pow(10, difference/ELO_GOALS_RATING_SCALE)
+	awayLambda := ELO_GOALS_PER_TEAM * math.Pow(10, -difference/ELO_GOALS_RATING_SCALE)
+
+	homeScore, awayScore := m.scoreModel.sampleScore(homeLambda, awayLambda, rng)
+
+	homeStrength := input.HomeTeam.DynamicAttributes.Elo
+	if !input.NeutralVenue {
+		homeStrength += input.HomeTeam.eloHomeAdvantage()
+	}
+
+	return MatchOutcome{
+		HomeScore: homeScore,
+		AwayScore: awayScore,
+		Strengths: MatchStrengths{
+			HomeStrength: homeStrength,
+			AwayStrength: input.AwayTeam.DynamicAttributes.Elo,
+			HomeLambda:   homeLambda,
+			AwayLambda:   awayLambda,
+		},
+	}, nil
+}
+
+// Position of each team when sorted by Elo rating, ties broken by name
+func powerRanking(teams map[string]*Team) map[string]int {
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		iElo := teams[names[i]].DynamicAttributes.Elo
+		jElo := teams[names[j]].DynamicAttributes.Elo
+		if iElo != jElo {
+			return iElo > jElo
+		}
+		return names[i] < names[j]
+	})
+
+	ranks := make(map[string]int)
+	for i, name := range names {
+		ranks[name] = i + 1
+	}
+	return ranks
+}
+
+func printStandingsElo(enableTerminalColors bool, elo float64) {
+	format := "%-6.0f"
+	if !enableTerminalColors {
+		fmt.Printf(format, elo)
+	} else {
+		ansi.Printf(ansi.BoldWhite, format, elo)
+	}
+}
+
+// Green when the team is stronger than its table position suggests, red when it is weaker
+func printStandingsPowerRank(enableTerminalColors bool, powerRank int, rank int) {
+	format := "%-6d"
+	if !enableTerminalColors {
+		fmt.Printf(format, powerRank)
+	} else if powerRank < rank {
+		ansi.Printf(ansi.BoldGreen, format, powerRank)
+	} else if powerRank > rank {
+		ansi.Printf(ansi.BoldRed, format, powerRank)
+	} else {
+		ansi.Printf(ansi.BoldWhite, format, powerRank)
+	}
+}
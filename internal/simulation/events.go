@@ -0,0 +1,225 @@
+package simulation
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	EVENTS_FORMAT_NDJSON = "ndjson"
+
+	EVENT_TYPE_SEASON_STARTED            = "season_started"
+	EVENT_TYPE_FIXTURE_PLAYED            = "fixture_played"
+	EVENT_TYPE_DYNAMIC_ATTRIBUTE_CHANGED = "dynamic_attribute_changed"
+	EVENT_TYPE_ROUND_FINISHED            = "round_finished"
+	EVENT_TYPE_GPT_EVENT_GENERATED       = "gpt_event_generated"
+	EVENT_TYPE_CHAMPION_DECIDED          = "champion_decided"
+
+	// Reasons for a dynamic attribute change
+	ATTRIBUTE_CHANGE_REASON_FIXTURE   = "fixture"
+	ATTRIBUTE_CHANGE_REASON_GPT_EVENT = "gpt_event"
+)
+
+// Writes domain events as NDJSON (one JSON object per line).
+// A nil emitter is valid and simply discards all events.
+type EventEmitter struct {
+	encoder *json.Encoder
+}
+
+type SeasonStartedEvent struct {
+	Type          string   `json:"type"`
+	Seed          *uint64  `json:"seed,omitempty"`
+	Teams         []string `json:"teams"`
+	Rounds        int      `json:"rounds"`
+	TieBreakChain []string `json:"tieBreakChain"`
+	DrawingOfLots []string `json:"drawingOfLots"`
+}
+
+type FixturePlayedEvent struct {
+	Type          string  `json:"type"`
+	Competition   string  `json:"competition,omitempty"`
+	Stage         string  `json:"stage,omitempty"`
+	NeutralVenue  bool    `json:"neutralVenue,omitempty"`
+	Round         int     `json:"round"`
+	Kickoff       string  `json:"kickoff,omitempty"`
+	HomeTeam      string  `json:"homeTeam"`
+	AwayTeam      string  `json:"awayTeam"`
+	HomeTeamScore int     `json:"homeTeamScore"`
+	AwayTeamScore int     `json:"awayTeamScore"`
+	HomeStrength  float64 `json:"homeStrength"`
+	AwayStrength  float64 `json:"awayStrength"`
+	HomeLambda    float64 `json:"homeLambda"`
+	AwayLambda    float64 `json:"awayLambda"`
+	// Days since the previous match of each team, -1 if unknown
+	HomeRestDays    int         `json:"homeRestDays"`
+	AwayRestDays    int         `json:"awayRestDays"`
+	HomeYellowCards int         `json:"homeYellowCards"`
+	HomeRedCards    int         `json:"homeRedCards"`
+	AwayYellowCards int         `json:"awayYellowCards"`
+	AwayRedCards    int         `json:"awayRedCards"`
+	Goals           []GoalEvent `json:"goals,omitempty"`
+	// Starting XIs, only for teams with a squad
+	HomeLineup []string `json:"homeLineup,omitempty"`
+	AwayLineup []string `json:"awayLineup,omitempty"`
+}
+
+type GoalEvent struct {
+	Team   string `json:"team"`
+	Scorer string `json:"scorer"`
+	Assist string `json:"assist,omitempty"`
+	Minute int    `json:"minute"`
+}
+
+type DynamicAttributeChangedEvent struct {
+	Type      string  `json:"type"`
+	Team      string  `json:"team"`
+	Attribute string  `json:"attribute"`
+	OldValue  float64 `json:"oldValue"`
+	NewValue  float64 `json:"newValue"`
+	Reason    string  `json:"reason"`
+}
+
+type RoundFinishedEvent struct {
+	Type      string         `json:"type"`
+	Round     int            `json:"round"`
+	Standings []StandingsRow `json:"standings"`
+}
+
+type GptEventGeneratedEvent struct {
+	Type    string `json:"type"`
+	Round   int    `json:"round"`
+	Team    string `json:"team"`
+	Message string `json:"message"`
+}
+
+type ChampionDecidedEvent struct {
+	Type string `json:"type"`
+	Team string `json:"team"`
+}
+
+func newEventEmitter(w io.Writer) *EventEmitter {
+	return &EventEmitter{encoder: json.NewEncoder(w)}
+}
+
+func (e *EventEmitter) emit(event interface{}) error {
+	if e == nil {
+		return nil
+	}
+	return e.encoder.Encode(event)
+}
+
+func (e *EventEmitter) seasonStarted(s *Season, seed *uint64) error {
+	return e.emit(SeasonStartedEvent{
+		Type:          EVENT_TYPE_SEASON_STARTED,
+		Seed:          seed,
+		Teams:         s.teamsGetAllNames(),
+		Rounds:        len(s.schedule.rounds),
+		TieBreakChain: tieBreakChainNames(s.tieBreakChain),
+		DrawingOfLots: s.drawingOfLots,
+	})
+}
+
+func (e *EventEmitter) fixturePlayed(context FixtureContext, f *Fixture, strengths MatchStrengths) error {
+	homeYellowCards, homeRedCards := f.cardsOf(f.homeTeam)
+	awayYellowCards, awayRedCards := f.cardsOf(f.awayTeam)
+
+	goals := []GoalEvent{}
+	for _, goal := range f.goals {
+		goals = append(goals, GoalEvent{Team: goal.team, Scorer: goal.scorer, Assist: goal.assist, Minute: goal.minute})
+	}
+
+	return e.emit(FixturePlayedEvent{
+		Type:            EVENT_TYPE_FIXTURE_PLAYED,
+		Competition:     context.Competition,
+		Stage:           context.Stage,
+		NeutralVenue:    context.NeutralVenue,
+		Round:           context.RoundIdx + 1,
+		Kickoff:         formatIsoKickoff(f.kickoff),
+		HomeTeam:        f.homeTeam,
+		AwayTeam:        f.awayTeam,
+		HomeTeamScore:   f.homeTeamScore,
+		AwayTeamScore:   f.awayTeamScore,
+		HomeStrength:    strengths.HomeStrength,
+		AwayStrength:    strengths.AwayStrength,
+		HomeLambda:      strengths.HomeLambda,
+		AwayLambda:      strengths.AwayLambda,
+		HomeRestDays:    strengths.HomeRestDays,
+		AwayRestDays:    strengths.AwayRestDays,
+		HomeYellowCards: homeYellowCards,
+		HomeRedCards:    homeRedCards,
+		AwayYellowCards: awayYellowCards,
+		AwayRedCards:    awayRedCards,
+		Goals:           goals,
+		HomeLineup:      f.homeLineup.playerNames(),
+		AwayLineup:      f.awayLineup.playerNames(),
+	})
+}
+
+// Emits one event for each dynamic attribute of the team that differs from the previous values
+func (e *EventEmitter) dynamicAttributesChanged(t *Team, previous TeamDynamicAttributes, reason string) error {
+	if e == nil {
+		return nil
+	}
+
+	changes := []struct {
+		attribute string
+		oldValue  float64
+		newValue  float64
+	}{
+		{TEAM_DYNAMIC_ATTRIBUTE_MORALE_NAME, previous.Morale, t.DynamicAttributes.Morale},
+		{TEAM_DYNAMIC_ATTRIBUTE_PHYSICAL_CONDITION_NAME, previous.PhysicalCondition, t.DynamicAttributes.PhysicalCondition},
+	}
+
+	for _, change := range changes {
+		if change.oldValue == change.newValue {
+			continue
+		}
+		err := e.emit(DynamicAttributeChangedEvent{
+			Type:      EVENT_TYPE_DYNAMIC_ATTRIBUTE_CHANGED,
+			Team:      t.Name,
+			Attribute: change.attribute,
+			OldValue:  change.oldValue,
+			NewValue:  change.newValue,
+			Reason:    reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *EventEmitter) roundFinished(s *Season) error {
+	if e == nil {
+		return nil
+	}
+
+	standings := s.standingsGenerate()
+	seasonExport, err := s.exportRows(standings)
+	if err != nil {
+		return err
+	}
+
+	return e.emit(RoundFinishedEvent{
+		Type:      EVENT_TYPE_ROUND_FINISHED,
+		Round:     s.schedule.currentRoundIdx + 1,
+		Standings: seasonExport.Standings,
+	})
+}
+
+func (e *EventEmitter) gptEventGenerated(roundIdx int, teamName string, message string) error {
+	return e.emit(GptEventGeneratedEvent{
+		Type:    EVENT_TYPE_GPT_EVENT_GENERATED,
+		Round:   roundIdx + 1,
+		Team:    teamName,
+		Message: message,
+	})
+}
+
+func (e *EventEmitter) championDecided(teamName string) error {
+	return e.emit(ChampionDecidedEvent{
+		Type: EVENT_TYPE_CHAMPION_DECIDED,
+		Team: teamName,
+	})
+}
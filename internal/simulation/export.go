@@ -0,0 +1,274 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	OUTPUT_FORMAT_CSV      = "csv"
+	OUTPUT_FORMAT_JSON     = "json"
+	OUTPUT_FORMAT_MARKDOWN = "markdown"
+	// Files of the CSV export, which has one table per file
+	EXPORT_STANDINGS_CSV_FILE = "standings.csv"
+	EXPORT_FIXTURES_CSV_FILE  = "fixtures.csv"
+)
+
+// A row of the standings table, with the same columns shown in the terminal
+type StandingsRow struct {
+	Rank              int     `json:"rank"`
+	Team              string  `json:"team"`
+	Matches           int     `json:"matches"`
+	Points            int     `json:"points"`
+	Won               int     `json:"won"`
+	Drawn             int     `json:"drawn"`
+	Lost              int     `json:"lost"`
+	GoalsFor          int     `json:"goalsFor"`
+	GoalsAgainst      int     `json:"goalsAgainst"`
+	GoalsDiff         int     `json:"goalsDiff"`
+	YellowCards       int     `json:"yellowCards"`
+	RedCards          int     `json:"redCards"`
+	RecentForm        string  `json:"recentForm"`
+	Change            int     `json:"change"`
+	Morale            float64 `json:"morale"`
+	PhysicalCondition float64 `json:"physicalCondition"`
+	Elo               float64 `json:"elo"`
+	PowerRank         int     `json:"powerRank"`
+	Zone              string  `json:"zone"`
+}
+
+type FixtureRow struct {
+	Round         int    `json:"round"`
+	Kickoff       string `json:"kickoff"`
+	HomeTeam      string `json:"homeTeam"`
+	AwayTeam      string `json:"awayTeam"`
+	HomeTeamScore *int   `json:"homeTeamScore"`
+	AwayTeamScore *int   `json:"awayTeamScore"`
+	Played        bool   `json:"played"`
+	// Goals in the order they were scored, e.g. 12' Estevao (Raphael Veiga), 67' Pedro
+	Scorers string `json:"scorers"`
+}
+
+type SeasonExport struct {
+	Standings []StandingsRow `json:"standings"`
+	Fixtures  []FixtureRow   `json:"fixtures"`
+}
+
+func isValidOutputFormat(format string) bool {
+	return format == OUTPUT_FORMAT_CSV || format == OUTPUT_FORMAT_JSON || format == OUTPUT_FORMAT_MARKDOWN
+}
+
+// Writes the standings and the schedule to outputFile (or to stdout, if outputFile is empty).
+// The CSV export is written to outputDir instead, as EXPORT_STANDINGS_CSV_FILE and EXPORT_FIXTURES_CSV_FILE.
+func (s *Season) export(standings Standings, format string, outputFile string, outputDir string) error {
+	seasonExport, err := s.exportRows(standings)
+	if err != nil {
+		return err
+	}
+
+	if format == OUTPUT_FORMAT_CSV {
+		return seasonExport.writeCsvFiles(outputDir)
+	}
+
+	var w io.Writer = os.Stdout
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch format {
+	case OUTPUT_FORMAT_JSON:
+		return seasonExport.writeJson(w)
+	case OUTPUT_FORMAT_MARKDOWN:
+		return seasonExport.writeMarkdown(w)
+	}
+
+	return fmt.Errorf("unknown output format [%s]", format)
+}
+
+func (s *Season) exportRows(standings Standings) (SeasonExport, error) {
+	seasonExport := SeasonExport{}
+	powerRanks := powerRanking(s.teams)
+
+	for i, teamStatistics := range standings.TeamStatistics {
+		team := s.teamsGetWithName(teamStatistics.Name)
+		teamRecentFiveGoalDiffs := getTeamRecentFiveGoalDiffs(teamStatistics.Name, team.DynamicAttributes.LastFixtures)
+		teamPositionChange, err := getTeamPositionChange(teamStatistics.Name, standings.TeamStatistics, standings.PreviousTeamStatistics)
+		if err != nil {
+			return SeasonExport{}, err
+		}
+
+		seasonExport.Standings = append(seasonExport.Standings, StandingsRow{
+			Rank:              i + 1,
+			Team:              teamStatistics.Name,
+			Matches:           teamStatistics.Matches,
+			Points:            teamStatistics.Points,
+			Won:               teamStatistics.Won,
+			Drawn:             teamStatistics.Drawn,
+			Lost:              teamStatistics.Lost,
+			GoalsFor:          teamStatistics.GoalsFor,
+			GoalsAgainst:      teamStatistics.GoalsAgainst,
+			GoalsDiff:         teamStatistics.GoalsDiff,
+			YellowCards:       teamStatistics.YellowCards,
+			RedCards:          teamStatistics.RedCards,
+			RecentForm:        formatRecentForm(teamRecentFiveGoalDiffs),
+			Change:            teamPositionChange,
+			Morale:            team.DynamicAttributes.Morale,
+			PhysicalCondition: team.DynamicAttributes.PhysicalCondition,
+			Elo:               team.DynamicAttributes.Elo,
+			PowerRank:         powerRanks[teamStatistics.Name],
+			Zone:              zoneNameForRank(s.zones, i+1),
+		})
+	}
+
+	for i, round := range s.schedule.rounds {
+		for _, fixture := range round.fixtures {
+			fixtureRow := FixtureRow{
+				Round:    i + 1,
+				Kickoff:  formatIsoKickoff(fixture.kickoff),
+				HomeTeam: fixture.homeTeam,
+				AwayTeam: fixture.awayTeam,
+				Played:   fixture.played,
+				Scorers:  fixture.formatGoals(),
+			}
+			if fixture.played {
+				homeTeamScore := fixture.homeTeamScore
+				awayTeamScore := fixture.awayTeamScore
+				fixtureRow.HomeTeamScore = &homeTeamScore
+				fixtureRow.AwayTeamScore = &awayTeamScore
+			}
+			seasonExport.Fixtures = append(seasonExport.Fixtures, fixtureRow)
+		}
+	}
+
+	return seasonExport, nil
+}
+
+// Recent form as a sequence of W (won), D (drawn), L (lost) and - (not played), oldest match first
+func formatRecentForm(lastFiveGoalDiffs [5]*int) string {
+	recentForm := ""
+	for _, goalDiff := range lastFiveGoalDiffs {
+		if goalDiff == nil {
+			recentForm += "-"
+		} else if *goalDiff > 0 {
+			recentForm += "W"
+		} else if *goalDiff < 0 {
+			recentForm += "L"
+		} else {
+			recentForm += "D"
+		}
+	}
+	return recentForm
+}
+
+func formatOptionalScore(score *int) string {
+	if score == nil {
+		return ""
+	}
+	return strconv.Itoa(*score)
+}
+
+func (r *StandingsRow) fields() []string {
+	return []string{
+		strconv.Itoa(r.Rank), r.Team, strconv.Itoa(r.Matches), strconv.Itoa(r.Points), strconv.Itoa(r.Won),
+		strconv.Itoa(r.Drawn), strconv.Itoa(r.Lost), strconv.Itoa(r.GoalsFor), strconv.Itoa(r.GoalsAgainst),
+		strconv.Itoa(r.GoalsDiff), strconv.Itoa(r.YellowCards), strconv.Itoa(r.RedCards), r.RecentForm, strconv.Itoa(r.Change),
+		fmt.Sprintf("%.2f", r.Morale), fmt.Sprintf("%.2f", r.PhysicalCondition), fmt.Sprintf("%.0f", r.Elo),
+		strconv.Itoa(r.PowerRank), r.Zone,
+	}
+}
+
+func (r *FixtureRow) fields() []string {
+	return []string{
+		strconv.Itoa(r.Round), r.Kickoff, r.HomeTeam, formatOptionalScore(r.HomeTeamScore), formatOptionalScore(r.AwayTeamScore), r.AwayTeam, r.Scorers,
+	}
+}
+
+var standingsHeader = []string{"Rank", "Team", "Matches", "Points", "Won", "Drawn", "Lost",
+	"GoalsFor", "GoalsAgainst", "GoalsDiff", "Yellow", "Red", "RecentForm", "Change", "Morale", "PhysCond", "Elo", "Power", "Zone"}
+
+var fixturesHeader = []string{"Round", "Kickoff", "HomeTeam", "HomeTeamScore", "AwayTeamScore", "AwayTeam", "Scorers"}
+
+// Each table is written to its own file, so the files can be read by any CSV reader
+func (e *SeasonExport) writeCsvFiles(outputDir string) error {
+	err := os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	standingsRecords := [][]string{standingsHeader}
+	for _, row := range e.Standings {
+		standingsRecords = append(standingsRecords, row.fields())
+	}
+	err = writeCsvFile(filepath.Join(outputDir, EXPORT_STANDINGS_CSV_FILE), standingsRecords)
+	if err != nil {
+		return err
+	}
+
+	fixturesRecords := [][]string{fixturesHeader}
+	for _, row := range e.Fixtures {
+		fixturesRecords = append(fixturesRecords, row.fields())
+	}
+	return writeCsvFile(filepath.Join(outputDir, EXPORT_FIXTURES_CSV_FILE), fixturesRecords)
+}
+
+func writeCsvFile(filePath string, records [][]string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	// WriteAll flushes the records and returns the first error of the writer
+	err = csv.NewWriter(file).WriteAll(records)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (e *SeasonExport) writeJson(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(e)
+}
+
+func (e *SeasonExport) writeMarkdown(w io.Writer) error {
+	fmt.Fprintf(w, "## Standings\n\n")
+	writeMarkdownRow(w, standingsHeader)
+	writeMarkdownSeparator(w, len(standingsHeader))
+	for _, row := range e.Standings {
+		writeMarkdownRow(w, row.fields())
+	}
+
+	fmt.Fprintf(w, "\n## Fixtures\n\n")
+	writeMarkdownRow(w, fixturesHeader)
+	writeMarkdownSeparator(w, len(fixturesHeader))
+	for _, row := range e.Fixtures {
+		writeMarkdownRow(w, row.fields())
+	}
+
+	return nil
+}
+
+func writeMarkdownRow(w io.Writer, fields []string) {
+	escapedFields := make([]string, 0, len(fields))
+	for _, field := range fields {
+		escapedFields = append(escapedFields, strings.ReplaceAll(field, "|", "\\|"))
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(escapedFields, " | "))
+}
+
+func writeMarkdownSeparator(w io.Writer, numColumns int) {
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", numColumns))
+}
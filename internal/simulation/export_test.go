@@ -0,0 +1,62 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Each CSV file holds a single table, readable by any CSV reader
+func TestCsvExportWritesOneTablePerFile(t *testing.T) {
+	season := playTestSeason(t, loadTestTeams(t), 1)
+	outputDir := filepath.Join(t.TempDir(), "export")
+
+	err := season.export(season.standingsGenerate(), OUTPUT_FORMAT_CSV, "", outputDir)
+	if err != nil {
+		t.Fatalf("unable to export: %v", err)
+	}
+
+	tests := []struct {
+		fileName   string
+		header     []string
+		numRecords int
+	}{
+		{EXPORT_STANDINGS_CSV_FILE, standingsHeader, len(season.teams)},
+		{EXPORT_FIXTURES_CSV_FILE, fixturesHeader, len(season.schedule.rounds) * len(season.schedule.rounds[0].fixtures)},
+	}
+
+	for _, test := range tests {
+		file, err := os.Open(filepath.Join(outputDir, test.fileName))
+		if err != nil {
+			t.Fatalf("%s: %v", test.fileName, err)
+		}
+		records, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: unable to read: %v", test.fileName, err)
+		}
+
+		if !reflect.DeepEqual(records[0], test.header) {
+			t.Errorf("%s: header is %v, expected %v", test.fileName, records[0], test.header)
+		}
+		if len(records)-1 != test.numRecords {
+			t.Errorf("%s: %d records, expected %d", test.fileName, len(records)-1, test.numRecords)
+		}
+	}
+}
+
+func TestCsvExportReportsWriteErrors(t *testing.T) {
+	season := playTestSeason(t, loadTestTeams(t), 1)
+	// The output directory can't be created under a file
+	filePath := filepath.Join(t.TempDir(), "file")
+	err := os.WriteFile(filePath, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := season.export(season.standingsGenerate(), OUTPUT_FORMAT_CSV, "", filepath.Join(filePath, "export")); err == nil {
+		t.Errorf("export to an invalid directory succeeded")
+	}
+}
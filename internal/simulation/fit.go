@@ -0,0 +1,324 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Midfield of the teams that are not in the teams directory. Midfield is not fitted, as it adds to both attack and defense.
+	FIT_DEFAULT_MIDFIELD = 5.0
+	// Expected goals below this value are raised to it, so a goal is never impossible
+	FIT_MIN_LAMBDA = 1e-6
+	// The fit stops when a full pass over the teams improves the log-likelihood by less than this
+	FIT_TOLERANCE      = 1e-6
+	FIT_MAX_ITERATIONS = 200
+)
+
+type FitOptions struct {
+	// CSV file with the past matches, in the same format as -fixtures. Unplayed fixtures are ignored.
+	MatchesFile string
+	// Directory with the current team files, whose Midfield is kept and against which the diffs are proposed.
+	// If empty, the dataset of Season (DATASET_CURRENT_SEASON by default) is used
+	TeamsDir string
+	// Season of the dataset with the current team files (see DATASETS_PATH), e.g. 2024
+	Season string
+	// Directory with the datasets. If empty, DATASETS_PATH is looked up in the working directory and next to the executable
+	DatasetsDir string
+	// If set, the fitted teams are written to this directory. Otherwise, the differences to the current teams are printed.
+	OutputDir string
+}
+
+// Team attributes estimated from past matches
+type FittedTeam struct {
+	Name       string
+	Attack     float64
+	Defense    float64
+	HomeFactor float64
+	// Current attributes, nil for teams that are not in the teams directory
+	current *Team
+	// Fixed, taken from the current team
+	midfield float64
+	matches  int
+}
+
+// Estimates the Attack, Defense and HomeFactor of each team by maximum likelihood, under the Poisson model used by the
+// attributes match model with neutral form, morale and physical condition
+func Fit(options FitOptions) {
+	if options.MatchesFile == "" {
+		fmt.Fprintf(os.Stderr, "A CSV file with the past matches is required (-matches)\n")
+		os.Exit(1)
+	}
+
+	importedFixtures, err := fixturesLoad(options.MatchesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load matches: %v\n", err)
+		os.Exit(1)
+	}
+
+	teamsPath, err := teamsPathResolve(options.TeamsDir, options.Season, options.DatasetsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	teams, err := teamsLoad(teamsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	matches := []ImportedFixture{}
+	for _, importedFixture := range importedFixtures {
+		if importedFixture.Played {
+			matches = append(matches, importedFixture)
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(os.Stderr, "No played matches in [%s]\n", options.MatchesFile)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Dataset: [%s]\n", datasetDescription(teamsPath, options.TeamsDir, options.Season))
+
+	fittedTeams := newFittedTeams(teams, matches)
+	initialLogLikelihood := fitLogLikelihood(fittedTeams, matches)
+	iterations := fitMaximizeLikelihood(fittedTeams, matches)
+	finalLogLikelihood := fitLogLikelihood(fittedTeams, matches)
+
+	fmt.Printf("Fitted [%d] teams to [%d] matches in [%d] iterations\n", len(fittedTeams), len(matches), iterations)
+	fmt.Printf("Log-likelihood: [%.2f] with the current attributes, [%.2f] with the fitted ones\n", initialLogLikelihood, finalLogLikelihood)
+
+	if options.OutputDir != "" {
+		err = writeFittedTeams(fittedTeams, options.OutputDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write teams: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Teams written to [%s]\n", options.OutputDir)
+		return
+	}
+
+	printFittedTeamsDiff(fittedTeams)
+}
+
+// Teams that played the matches, starting from their current attributes (or neutral ones, for unknown teams)
+func newFittedTeams(teams []*Team, matches []ImportedFixture) map[string]*FittedTeam {
+	currentTeams := make(map[string]*Team)
+	for _, team := range teams {
+		currentTeams[team.Name] = team
+	}
+
+	fittedTeams := make(map[string]*FittedTeam)
+	for _, match := range matches {
+		for _, name := range []string{match.HomeTeam, match.AwayTeam} {
+			fittedTeam, ok := fittedTeams[name]
+			if !ok {
+				fittedTeam = &FittedTeam{Name: name, Attack: 5, Defense: 5, HomeFactor: 5, midfield: FIT_DEFAULT_MIDFIELD}
+				if current, ok := currentTeams[name]; ok {
+					fittedTeam.current = current
+					fittedTeam.Attack = current.Attack
+					fittedTeam.Defense = current.Defense
+					fittedTeam.HomeFactor = current.HomeFactor
+					fittedTeam.midfield = current.Midfield
+				}
+				fittedTeams[name] = fittedTeam
+			}
+			fittedTeam.matches += 1
+		}
+	}
+
+	return fittedTeams
+}
+
+// Expected goals of both teams, as computed by the attributes match model when form, morale and physical condition are neutral
+func fitLambdas(homeTeam *FittedTeam, awayTeam *FittedTeam) (float64, float64) {
+	homeStrength := homeFactorStrength(homeTeam.HomeFactor) * rawStrength(homeTeam.Attack, homeTeam.midfield, awayTeam.Defense, awayTeam.midfield)
+	awayStrength := rawStrength(awayTeam.Attack, awayTeam.midfield, homeTeam.Defense, homeTeam.midfield)
+	return util.AttenuateStrength(homeStrength), util.AttenuateStrength(awayStrength)
+}
+
+func fitLogLikelihood(fittedTeams map[string]*FittedTeam, matches []ImportedFixture) float64 {
+	logLikelihood := 0.0
+	for _, match := range matches {
+		homeLambda, awayLambda := fitLambdas(fittedTeams[match.HomeTeam], fittedTeams[match.AwayTeam])
+		logLikelihood += poissonLogProbability(match.HomeTeamScore, fitClampLambda(homeLambda)) + poissonLogProbability(match.AwayTeamScore, fitClampLambda(awayLambda))
+	}
+	return logLikelihood
+}
+
+func fitClampLambda(lambda float64) float64 {
+	// Also catches NaN, e.g. when neither team has any strength
+	if !(lambda > FIT_MIN_LAMBDA) {
+		return FIT_MIN_LAMBDA
+	}
+	return lambda
+}
+
+// Coordinate ascent: each attribute of each team is maximized in turn, keeping the others fixed, until the likelihood stops improving.
+// Returns the number of passes over the teams.
+func fitMaximizeLikelihood(fittedTeams map[string]*FittedTeam, matches []ImportedFixture) int {
+	names := make([]string, 0, len(fittedTeams))
+	for name := range fittedTeams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Only the matches of a team change when one of its attributes does
+	teamMatches := make(map[string][]ImportedFixture)
+	for _, match := range matches {
+		teamMatches[match.HomeTeam] = append(teamMatches[match.HomeTeam], match)
+		teamMatches[match.AwayTeam] = append(teamMatches[match.AwayTeam], match)
+	}
+
+	logLikelihood := fitLogLikelihood(fittedTeams, matches)
+
+	for iteration := 1; iteration <= FIT_MAX_ITERATIONS; iteration++ {
+		for _, name := range names {
+			fittedTeam := fittedTeams[name]
+			for _, attribute := range []*float64{&fittedTeam.Attack, &fittedTeam.Defense, &fittedTeam.HomeFactor} {
+				*attribute = goldenSectionMaximize(0, 10, func(value float64) float64 {
+					*attribute = value
+					return fitLogLikelihood(fittedTeams, teamMatches[name])
+				})
+			}
+		}
+
+		newLogLikelihood := fitLogLikelihood(fittedTeams, matches)
+		if newLogLikelihood-logLikelihood < FIT_TOLERANCE {
+			return iteration
+		}
+		logLikelihood = newLogLikelihood
+	}
+
+	return FIT_MAX_ITERATIONS
+}
+
+// Finds the maximum of a unimodal function in the interval [min, max]
+func goldenSectionMaximize(min float64, max float64, f func(float64) float64) float64 {
+	const tolerance = 1e-4
+	ratio := (math.Sqrt(5) - 1) / 2
+
+	a, b := min, max
+	c := b - ratio*(b-a)
+	d := a + ratio*(b-a)
+	fc, fd := f(c), f(d)
+
+	for b-a > tolerance {
+		if fc > fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = f(d)
+		}
+	}
+
+	// The maximum may be at the bounds of the interval
+	best := (a + b) / 2
+	bestValue := f(best)
+	for _, bound := range []float64{min, max} {
+		if value := f(bound); value > bestValue {
+			best, bestValue = bound, value
+		}
+	}
+	return best
+}
+
+// Attributes are rounded to one decimal place, as the model is not precise beyond that
+func roundAttribute(value float64) float64 {
+	return math.Round(value*10) / 10
+}
+
+func (t *FittedTeam) toTeamFile() teamFile {
+	file := teamFile{
+		Name:       t.Name,
+		Attack:     roundAttribute(t.Attack),
+		Midfield:   t.midfield,
+		Defense:    roundAttribute(t.Defense),
+		HomeFactor: roundAttribute(t.HomeFactor),
+	}
+	if t.current != nil {
+		file.Country = t.current.Country
+	}
+	return file
+}
+
+// Teams keep the name of their current file. New teams get one named after them, e.g. sao-paulo.json
+func (t *FittedTeam) fileName() string {
+	if t.current != nil && t.current.filePath != "" {
+		return filepath.Base(t.current.filePath)
+	}
+	return strings.ToLower(strings.ReplaceAll(t.Name, " ", "-")) + ".json"
+}
+
+func writeFittedTeams(fittedTeams map[string]*FittedTeam, outputDir string) error {
+	err := os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, fittedTeam := range fittedTeams {
+		raw, err := json.MarshalIndent(fittedTeam.toTeamFile(), "", "\t")
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(outputDir, fittedTeam.fileName()), append(raw, '\n'), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Prints the proposed changes to each team file, e.g. Attack: 3 -> 4.2
+func printFittedTeamsDiff(fittedTeams map[string]*FittedTeam) {
+	names := make([]string, 0, len(fittedTeams))
+	for name := range fittedTeams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fittedTeam := fittedTeams[name]
+		fitted := fittedTeam.toTeamFile()
+
+		if fittedTeam.current == nil {
+			fmt.Printf("\n%s (new file %s, %d matches)\n", name, fittedTeam.fileName(), fittedTeam.matches)
+			fmt.Printf("\tAttack: %g, Midfield: %g, Defense: %g, HomeFactor: %g\n", fitted.Attack, fitted.Midfield, fitted.Defense, fitted.HomeFactor)
+			continue
+		}
+
+		fmt.Printf("\n%s (%s, %d matches)\n", name, fittedTeam.current.filePath, fittedTeam.matches)
+		changes := []struct {
+			attribute string
+			current   float64
+			fitted    float64
+		}{
+			{"Attack", fittedTeam.current.Attack, fitted.Attack},
+			{"Defense", fittedTeam.current.Defense, fitted.Defense},
+			{"HomeFactor", fittedTeam.current.HomeFactor, fitted.HomeFactor},
+		}
+		unchanged := true
+		for _, change := range changes {
+			if change.current != change.fitted {
+				fmt.Printf("\t%s: %g -> %g\n", change.attribute, change.current, change.fitted)
+				unchanged = false
+			}
+		}
+		if unchanged {
+			fmt.Printf("\tNo changes\n")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestGoldenSectionMaximize(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(float64) float64
+		expected float64
+	}{
+		{"interior maximum", func(x float64) float64 { return -(x - 3.7) * (x - 3.7) }, 3.7},
+		{"increasing", func(x float64) float64 { return x }, 10},
+		{"decreasing", func(x float64) float64 { return -x }, 0},
+	}
+
+	for _, test := range tests {
+		if maximum := goldenSectionMaximize(0, 10, test.f); math.Abs(maximum-test.expected) > 1e-3 {
+			t.Errorf("%s: maximum at %.4f, expected %.4f", test.name, maximum, test.expected)
+		}
+	}
+}
+
+func TestFitLogLikelihood(t *testing.T) {
+	home := &FittedTeam{Name: "Home", Attack: 7, Defense: 4, HomeFactor: 8, midfield: 6}
+	away := &FittedTeam{Name: "Away", Attack: 3, Defense: 5, HomeFactor: 2, midfield: 4}
+	fittedTeams := map[string]*FittedTeam{"Home": home, "Away": away}
+	matches := []ImportedFixture{
+		{Round: 1, HomeTeam: "Home", AwayTeam: "Away", HomeTeamScore: 2, AwayTeamScore: 1, Played: true},
+		{Round: 2, HomeTeam: "Away", AwayTeam: "Home", HomeTeamScore: 0, AwayTeamScore: 3, Played: true},
+	}
+
+	lambda1Home, lambda1Away := fitLambdas(home, away)
+	lambda2Home, lambda2Away := fitLambdas(away, home)
+	expected := math.Log(poissonProbability(2, lambda1Home)*poissonProbability(1, lambda1Away)) +
+		math.Log(poissonProbability(0, lambda2Home)*poissonProbability(3, lambda2Away))
+
+	if logLikelihood := fitLogLikelihood(fittedTeams, matches); math.Abs(logLikelihood-expected) > 1e-9 {
+		t.Errorf("log-likelihood is %.6f, expected %.6f", logLikelihood, expected)
+	}
+
+	// Home advantage only helps the home team
+	if lambda1Home <= lambda2Away {
+		t.Errorf("expected goals at home (%.3f) are not above those away (%.3f)", lambda1Home, lambda2Away)
+	}
+}
+
+// Matches drawn from known attributes are fitted at least as well as the attributes that generated them,
+// and no small change of a fitted attribute improves the fit
+func TestFitMaximizeLikelihood(t *testing.T) {
+	trueTeams := map[string]*FittedTeam{
+		"A": {Name: "A", Attack: 8, Defense: 7, HomeFactor: 7, midfield: 6},
+		"B": {Name: "B", Attack: 6, Defense: 4, HomeFactor: 3, midfield: 5},
+		"C": {Name: "C", Attack: 4, Defense: 6, HomeFactor: 5, midfield: 5},
+		"D": {Name: "D", Attack: 3, Defense: 3, HomeFactor: 8, midfield: 4},
+	}
+	names := []string{"A", "B", "C", "D"}
+
+	rng := util.NewRng(3)
+	matches := []ImportedFixture{}
+	for round := 1; round <= 30; round++ {
+		for _, homeName := range names {
+			for _, awayName := range names {
+				if homeName == awayName {
+					continue
+				}
+				homeLambda, awayLambda := fitLambdas(trueTeams[homeName], trueTeams[awayName])
+				matches = append(matches, ImportedFixture{Round: round, HomeTeam: homeName, AwayTeam: awayName,
+					HomeTeamScore: util.PoissonKnuth(rng, homeLambda), AwayTeamScore: util.PoissonKnuth(rng, awayLambda), Played: true})
+			}
+		}
+	}
+
+	// Start from neutral attributes, keeping the midfield, which is not fitted
+	fittedTeams := newFittedTeams(nil, matches)
+	for name, fittedTeam := range fittedTeams {
+		fittedTeam.midfield = trueTeams[name].midfield
+	}
+
+	initialLogLikelihood := fitLogLikelihood(fittedTeams, matches)
+	iterations := fitMaximizeLikelihood(fittedTeams, matches)
+	if iterations >= FIT_MAX_ITERATIONS {
+		t.Errorf("fit didn't converge in %d iterations", FIT_MAX_ITERATIONS)
+	}
+
+	finalLogLikelihood := fitLogLikelihood(fittedTeams, matches)
+	trueLogLikelihood := fitLogLikelihood(trueTeams, matches)
+	if finalLogLikelihood < initialLogLikelihood {
+		t.Errorf("log-likelihood decreased from %.2f to %.2f", initialLogLikelihood, finalLogLikelihood)
+	}
+	if finalLogLikelihood < trueLogLikelihood-1e-3 {
+		t.Errorf("fitted log-likelihood %.4f is below the one of the true attributes %.4f", finalLogLikelihood, trueLogLikelihood)
+	}
+
+	for _, name := range names {
+		fittedTeam := fittedTeams[name]
+		for _, attribute := range []*float64{&fittedTeam.Attack, &fittedTeam.Defense, &fittedTeam.HomeFactor} {
+			if *attribute < 0 || *attribute > 10 {
+				t.Errorf("team %s: fitted attribute %.2f is out of the 0-10 range", name, *attribute)
+			}
+
+			fitted := *attribute
+			for _, change := range []float64{-0.05, 0.05} {
+				*attribute = math.Min(math.Max(fitted+change, 0), 10)
+				if logLikelihood := fitLogLikelihood(fittedTeams, matches); logLikelihood > finalLogLikelihood+1e-3 {
+					t.Errorf("team %s: changing an attribute from %.3f to %.3f improves the log-likelihood from %.4f to %.4f", name, fitted, *attribute, finalLogLikelihood, logLikelihood)
+				}
+			}
+			*attribute = fitted
+		}
+	}
+}
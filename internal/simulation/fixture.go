@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"errors"
+	"time"
 
 	"github.com/felipeek/brasileirao-simulation/internal/util"
 )
@@ -12,6 +13,17 @@ type Fixture struct {
 	homeTeamScore int
 	awayTeamScore int
 	played        bool
+	// Zero if the fixture has no date (e.g. seasons saved before the calendar existed)
+	kickoff time.Time
+	// Starting XIs, goals and cards, set when the fixture is played
+	homeLineup *Lineup
+	awayLineup *Lineup
+	goals      []Goal
+	cards      []Card
+}
+
+func newFixture(homeTeam string, awayTeam string) *Fixture {
+	return &Fixture{homeTeam: homeTeam, awayTeam: awayTeam, homeTeamScore: -1, awayTeamScore: -1}
 }
 
 const (
@@ -23,75 +35,92 @@ const (
 
 var recentFormMatchContributions = [5]float64{0.35, 0.20, 0.15, 0.15, 0.15}
 
-func (f *Fixture) play() error {
-	homeTeam := teamsGetWithName(f.homeTeam)
-	awayTeam := teamsGetWithName(f.awayTeam)
+// Intermediate values computed when playing a fixture, useful to understand its result
+type MatchStrengths struct {
+	HomeStrength float64
+	AwayStrength float64
+	HomeLambda   float64
+	AwayLambda   float64
+	// Days since the previous match of each team, or -1 if unknown
+	HomeRestDays int
+	AwayRestDays int
+}
 
-	// Additional strength given to the home team (home factor)
-	homeStadiumStrength := HOME_BONUS_FACTOR * (homeTeam.HomeFactor / 10)
+func (f *Fixture) play(homeTeam *Team, awayTeam *Team, neutralVenue bool, matchModel MatchModel, rng *util.Rng) (MatchStrengths, error) {
 
-	// Calculate home team recent form contribution
-	homeTeamRawFormContribution, err := calculateFormContribution(f.homeTeam, homeTeam.DynamicAttributes.LastFixtures)
+	// Days since the previous match of each team, used to recover their physical condition
+	homeRestDays := restDays(homeTeam, f)
+	awayRestDays := restDays(awayTeam, f)
+	homeTeam.recoverPhysicalCondition(homeRestDays)
+	awayTeam.recoverPhysicalCondition(awayRestDays)
+
+	// Pick the starting XIs, whose attributes replace the aggregate ones of teams with squads
+	var err error
+	f.homeLineup, err = homeTeam.pickLineup()
 	if err != nil {
-		return err
+		return MatchStrengths{}, err
 	}
-	homeTeamFormContribution := util.GetMultiplierFromContributionFactor(homeTeamRawFormContribution, RECENT_FORM_CONTRIBUTION_IMPACT)
-
-	// Calculate away team recent form contribution
-	awayTeamRawFormContribution, err := calculateFormContribution(f.awayTeam, awayTeam.DynamicAttributes.LastFixtures)
+	f.awayLineup, err = awayTeam.pickLineup()
 	if err != nil {
-		return err
+		return MatchStrengths{}, err
 	}
-	awayTeamFormContribution := util.GetMultiplierFromContributionFactor(awayTeamRawFormContribution, RECENT_FORM_CONTRIBUTION_IMPACT)
 
-	// Caclulate morale contribution
-	homeTeamMoraleContribution := util.GetMultiplierFromContributionFactor(homeTeam.DynamicAttributes.Morale, MORALE_CONTRIBUTION_IMPACT)
-	awayTeamMoraleContribution := util.GetMultiplierFromContributionFactor(awayTeam.DynamicAttributes.Morale, MORALE_CONTRIBUTION_IMPACT)
-
-	// Caclulate physical condition contribution
-	homeTeamPhysicalConditionContribution := util.GetMultiplierFromContributionFactor(homeTeam.DynamicAttributes.PhysicalCondition, PHYSICAL_CONDITION_CONTRIBUTION_IMPACT)
-	awayTeamPhysicalConditionContribution := util.GetMultiplierFromContributionFactor(awayTeam.DynamicAttributes.PhysicalCondition, PHYSICAL_CONDITION_CONTRIBUTION_IMPACT)
+	outcome, err := matchModel.playMatch(MatchInput{
+		HomeTeam:     homeTeam,
+		AwayTeam:     awayTeam,
+		HomeLineup:   f.homeLineup,
+		AwayLineup:   f.awayLineup,
+		NeutralVenue: neutralVenue,
+	}, rng)
+	if err != nil {
+		return MatchStrengths{}, err
+	}
 
-	// Home team strength factors
-	homeAttackStrength := 1.5*homeTeam.Attack + homeTeam.Midfield
-	homeDefenseStrength := 1.5*homeTeam.Defense + homeTeam.Midfield
+	f.homeTeamScore = outcome.HomeScore
+	f.awayTeamScore = outcome.AwayScore
 
-	// Away team strength factors
-	awayDefenseStrength := 1.5*awayTeam.Defense + awayTeam.Midfield
-	awayAttackStrength := 1.5*awayTeam.Attack + awayTeam.Midfield
+	// Models that don't produce goals leave them to be attributed to the players of the starting XIs
+	f.goals = outcome.Goals
+	if f.goals == nil {
+		f.goals = append(generateGoals(homeTeam, f.homeLineup, f.homeTeamScore, rng), generateGoals(awayTeam, f.awayLineup, f.awayTeamScore, rng)...)
+	}
+	f.sortGoals()
+	f.cards = append(generateCards(homeTeam, f.homeLineup, rng), generateCards(awayTeam, f.awayLineup, rng)...)
 
-	// Calculate home/away strength without other contributions
-	homeRawStrength := homeAttackStrength / (1 + awayDefenseStrength/homeAttackStrength)
-	awayRawStrength := awayAttackStrength / (1 + homeDefenseStrength/awayAttackStrength)
+	f.played = true
 
-	// Final non-attentuated strength of each team for this match
-	homeStrength := homeStadiumStrength * homeTeamFormContribution * homeTeamMoraleContribution * homeTeamPhysicalConditionContribution * homeRawStrength
-	awayStrength := awayTeamFormContribution * awayTeamMoraleContribution * awayTeamPhysicalConditionContribution * awayRawStrength
+	strengths := outcome.Strengths
+	strengths.HomeRestDays = homeRestDays
+	strengths.AwayRestDays = awayRestDays
 
-	// Attenuate strengths by employing a log-based function
-	homeLambda := util.AttenuateStrength(homeStrength)
-	awayLambda := util.AttenuateStrength(awayStrength)
+	updateEloRatings(homeTeam, awayTeam, f, neutralVenue)
 
-	// Generate final scores based on a poisson distribution
-	f.homeTeamScore = util.PoissonKnuth(homeLambda)
-	f.awayTeamScore = util.PoissonKnuth(awayLambda)
+	err = homeTeam.updateDynamicAttributes(f, rng)
+	if err != nil {
+		return MatchStrengths{}, err
+	}
 
-	//fmt.Printf("%s: %f -> %f\n", f.homeTeam, homeStrength, homeLambda)
-	//fmt.Printf("%s: %f -> %f\n\n", f.awayTeam, awayStrength, awayLambda)
+	err = awayTeam.updateDynamicAttributes(f, rng)
+	if err != nil {
+		return MatchStrengths{}, err
+	}
 
-	f.played = true
+	return strengths, nil
+}
 
-	err = homeTeam.updateDynamicAttributes(f)
-	if err != nil {
-		return err
+// Days between the previous match of the team and the received fixture, or -1 if unknown.
+// Must be called before the fixture is added to the team's last fixtures.
+func restDays(team *Team, f *Fixture) int {
+	if len(team.DynamicAttributes.LastFixtures) == 0 {
+		return -1
 	}
 
-	err = awayTeam.updateDynamicAttributes(f)
-	if err != nil {
-		return err
+	previous := team.DynamicAttributes.LastFixtures[0]
+	if previous.kickoff.IsZero() || f.kickoff.IsZero() {
+		return -1
 	}
 
-	return nil
+	return int(f.kickoff.Truncate(24*time.Hour).Sub(previous.kickoff.Truncate(24*time.Hour)).Hours() / 24)
 }
 
 // Return a contribution based on recent form in the interval 0-10
@@ -0,0 +1,173 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Probability of a goal having an assist
+	ASSIST_PROBABILITY = 0.7
+	// Number of players listed in the top scorers and top assists tables
+	LEADERBOARD_SIZE = 10
+	MATCH_MINUTES    = 90
+)
+
+// How likely a player of each position is to score or assist a goal, multiplied by their rating
+var scoringWeights = map[string]float64{
+	PLAYER_POSITION_GOALKEEPER: 0.01,
+	PLAYER_POSITION_DEFENDER:   0.5,
+	PLAYER_POSITION_MIDFIELDER: 1.5,
+	PLAYER_POSITION_FORWARD:    4.0,
+}
+
+var assistWeights = map[string]float64{
+	PLAYER_POSITION_GOALKEEPER: 0.05,
+	PLAYER_POSITION_DEFENDER:   0.8,
+	PLAYER_POSITION_MIDFIELDER: 2.5,
+	PLAYER_POSITION_FORWARD:    1.5,
+}
+
+type Goal struct {
+	team   string
+	scorer string
+	// Empty if the goal had no assist
+	assist string
+	minute int
+}
+
+// Goals or assists of a player in the season
+type PlayerTally struct {
+	Player string
+	Team   string
+	Count  int
+}
+
+// Players that may score, with their position and rating.
+// Teams without a squad have generic players, one for each position of the formation, all with the same rating.
+func goalCandidates(team *Team, lineup *Lineup) []*Player {
+	if len(lineup.players) > 0 {
+		return lineup.players
+	}
+
+	candidates := []*Player{}
+	for _, slot := range lineupFormation {
+		for i := 0; i < slot.count; i++ {
+			candidates = append(candidates, &Player{Name: genericPlayerName(team.Name, len(candidates)), Position: slot.position, Rating: 1})
+		}
+	}
+	return candidates
+}
+
+func pickWeightedPlayer(candidates []*Player, weights map[string]float64, excluded string, rng *util.Rng) string {
+	playerWeights := make([]float64, 0, len(candidates))
+	for _, player := range candidates {
+		if player.Name == excluded {
+			playerWeights = append(playerWeights, 0)
+		} else {
+			playerWeights = append(playerWeights, weights[player.Position]*player.Rating)
+		}
+	}
+	return candidates[util.RandomWeightedIndex(rng, playerWeights)].Name
+}
+
+// Attributes the received number of goals of a team to players of its starting XI, with a minute and an optional assist
+func generateGoals(team *Team, lineup *Lineup, numGoals int, rng *util.Rng) []Goal {
+	candidates := goalCandidates(team, lineup)
+	goals := []Goal{}
+
+	for i := 0; i < numGoals; i++ {
+		goal := Goal{team: team.Name, minute: 1 + util.RandomInt(rng, MATCH_MINUTES)}
+		goal.scorer = pickWeightedPlayer(candidates, scoringWeights, "", rng)
+		if rng.Float64() < ASSIST_PROBABILITY {
+			goal.assist = pickWeightedPlayer(candidates, assistWeights, goal.scorer, rng)
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals
+}
+
+// Goals of both teams, in the order they were scored
+func (f *Fixture) sortGoals() {
+	sort.SliceStable(f.goals, func(i, j int) bool {
+		return f.goals[i].minute < f.goals[j].minute
+	})
+}
+
+// Scorers of the fixture, e.g. 12' Estevao (Raphael Veiga), 67' Pedro
+func (f *Fixture) formatGoals() string {
+	descriptions := make([]string, 0, len(f.goals))
+	for _, goal := range f.goals {
+		description := fmt.Sprintf("%d' %s", goal.minute, goal.scorer)
+		if goal.assist != "" {
+			description += fmt.Sprintf(" (%s)", goal.assist)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// Top scorers and top assists of the league fixtures played until the received round
+func leaderboardsUntilRound(schedule *Schedule, roundIdx int) ([]PlayerTally, []PlayerTally) {
+	goals := make(map[PlayerTally]int)
+	assists := make(map[PlayerTally]int)
+
+	for i := 0; i <= roundIdx; i++ {
+		for _, fixture := range schedule.rounds[i].fixtures {
+			for _, goal := range fixture.goals {
+				goals[PlayerTally{Player: goal.scorer, Team: goal.team}] += 1
+				if goal.assist != "" {
+					assists[PlayerTally{Player: goal.assist, Team: goal.team}] += 1
+				}
+			}
+		}
+	}
+
+	return leaderboard(goals), leaderboard(assists)
+}
+
+// The players with the highest counts, ties broken by name
+func leaderboard(counts map[PlayerTally]int) []PlayerTally {
+	tallies := make([]PlayerTally, 0, len(counts))
+	for tally, count := range counts {
+		tally.Count = count
+		tallies = append(tallies, tally)
+	}
+
+	sort.Slice(tallies, func(i, j int) bool {
+		if tallies[i].Count != tallies[j].Count {
+			return tallies[i].Count > tallies[j].Count
+		}
+		return tallies[i].Player < tallies[j].Player
+	})
+
+	if len(tallies) > LEADERBOARD_SIZE {
+		tallies = tallies[:LEADERBOARD_SIZE]
+	}
+	return tallies
+}
+
+func printLeaderboards(topScorers []PlayerTally, topAssists []PlayerTally) {
+	if len(topScorers) == 0 {
+		return
+	}
+
+	format := "%-6s %-28s %-20s %-6s   %-28s %-20s %-6s\n"
+	fmt.Printf(format, "Rank", "Top scorers", "Team", "Goals", "Top assists", "Team", "Assists")
+
+	for i := 0; i < len(topScorers) || i < len(topAssists); i++ {
+		scorer, scorerTeam, goals := "", "", ""
+		if i < len(topScorers) {
+			scorer, scorerTeam, goals = topScorers[i].Player, topScorers[i].Team, fmt.Sprint(topScorers[i].Count)
+		}
+		assister, assisterTeam, assists := "", "", ""
+		if i < len(topAssists) {
+			assister, assisterTeam, assists = topAssists[i].Player, topAssists[i].Team, fmt.Sprint(topAssists[i].Count)
+		}
+		fmt.Printf(format, fmt.Sprint(i+1), scorer, scorerTeam, goals, assister, assisterTeam, assists)
+	}
+}
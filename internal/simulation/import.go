@@ -0,0 +1,194 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+// A fixture read from a CSV file with the real schedule and the results so far.
+// Each line has the format: round,home,away,home score,away score
+// Blank scores mean that the fixture was not played yet.
+type ImportedFixture struct {
+	Round         int
+	HomeTeam      string
+	AwayTeam      string
+	HomeTeamScore int
+	AwayTeamScore int
+	Played        bool
+}
+
+func fixturesLoad(fixturesPath string) ([]ImportedFixture, error) {
+	file, err := os.Open(fixturesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]ImportedFixture, 0, len(records))
+
+	for i, record := range records {
+		round, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			// The first line may be a header
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid round [%s]", i+1, record[0])
+		}
+
+		fixture := ImportedFixture{
+			Round:    round,
+			HomeTeam: strings.TrimSpace(record[1]),
+			AwayTeam: strings.TrimSpace(record[2]),
+		}
+
+		homeScore := strings.TrimSpace(record[3])
+		awayScore := strings.TrimSpace(record[4])
+		if homeScore != "" || awayScore != "" {
+			fixture.HomeTeamScore, err = strconv.Atoi(homeScore)
+			if err != nil || fixture.HomeTeamScore < 0 {
+				return nil, fmt.Errorf("line %d: invalid home score [%s]", i+1, homeScore)
+			}
+			fixture.AwayTeamScore, err = strconv.Atoi(awayScore)
+			if err != nil || fixture.AwayTeamScore < 0 {
+				return nil, fmt.Errorf("line %d: invalid away score [%s]", i+1, awayScore)
+			}
+			fixture.Played = true
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// Creates a season whose schedule is the imported one.
+// Played fixtures are replayed through Team.updateDynamicAttributes in round order to rebuild form, morale, physical condition and Elo ratings,
+// so only the remaining fixtures are simulated.
+func newSeasonFromImportedFixtures(teams []*Team, importedFixtures []ImportedFixture, rng *util.Rng) (*Season, error) {
+	season, err := newSeasonWithoutSchedule(teams, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	roundsMap := make(map[int]*Round)
+	// Teams that already play in each round, and the round of each imported fixture (home team, then away team)
+	roundTeams := make(map[int]map[string]bool)
+	fixtureRounds := make(map[[2]string]int)
+	for _, importedFixture := range importedFixtures {
+		if season.teamsGetWithName(importedFixture.HomeTeam) == nil {
+			return nil, fmt.Errorf("round %d: unknown team [%s]", importedFixture.Round, importedFixture.HomeTeam)
+		}
+		if season.teamsGetWithName(importedFixture.AwayTeam) == nil {
+			return nil, fmt.Errorf("round %d: unknown team [%s]", importedFixture.Round, importedFixture.AwayTeam)
+		}
+		if importedFixture.HomeTeam == importedFixture.AwayTeam {
+			return nil, fmt.Errorf("round %d: team [%s] plays against itself", importedFixture.Round, importedFixture.HomeTeam)
+		}
+
+		pairing := [2]string{importedFixture.HomeTeam, importedFixture.AwayTeam}
+		if otherRound, ok := fixtureRounds[pairing]; ok {
+			return nil, fmt.Errorf("round %d: fixture [%s] x [%s] is already in round %d", importedFixture.Round, importedFixture.HomeTeam, importedFixture.AwayTeam, otherRound)
+		}
+		fixtureRounds[pairing] = importedFixture.Round
+
+		round, ok := roundsMap[importedFixture.Round]
+		if !ok {
+			round = &Round{}
+			roundsMap[importedFixture.Round] = round
+			roundTeams[importedFixture.Round] = make(map[string]bool)
+		}
+		for _, teamName := range pairing {
+			if roundTeams[importedFixture.Round][teamName] {
+				return nil, fmt.Errorf("round %d: team [%s] plays more than once", importedFixture.Round, teamName)
+			}
+			roundTeams[importedFixture.Round][teamName] = true
+		}
+
+		fixture := newFixture(importedFixture.HomeTeam, importedFixture.AwayTeam)
+		if importedFixture.Played {
+			fixture.homeTeamScore = importedFixture.HomeTeamScore
+			fixture.awayTeamScore = importedFixture.AwayTeamScore
+			fixture.played = true
+		}
+		round.fixtures = append(round.fixtures, fixture)
+	}
+
+	roundNumbers := make([]int, 0, len(roundsMap))
+	for roundNumber := range roundsMap {
+		roundNumbers = append(roundNumbers, roundNumber)
+	}
+	sort.Ints(roundNumbers)
+
+	for i, roundNumber := range roundNumbers {
+		if roundNumber != i+1 {
+			return nil, fmt.Errorf("rounds must be numbered from 1 without gaps (round %d is missing)", i+1)
+		}
+		season.schedule.rounds = append(season.schedule.rounds, roundsMap[roundNumber])
+	}
+
+	if len(season.schedule.rounds) == 0 {
+		return nil, fmt.Errorf("no fixtures were imported")
+	}
+
+	season.schedule.assignDatesIfAvailable(season.calendar)
+
+	for _, round := range season.schedule.rounds {
+		for _, fixture := range round.fixtures {
+			if !fixture.played {
+				continue
+			}
+
+			homeTeam := season.teamsGetWithName(fixture.homeTeam)
+			awayTeam := season.teamsGetWithName(fixture.awayTeam)
+
+			// As in Fixture.play, teams recover their physical condition between matches
+			homeTeam.recoverPhysicalCondition(restDays(homeTeam, fixture))
+			awayTeam.recoverPhysicalCondition(restDays(awayTeam, fixture))
+
+			updateEloRatings(homeTeam, awayTeam, fixture, false)
+			err := homeTeam.updateDynamicAttributes(fixture, rng)
+			if err != nil {
+				return nil, err
+			}
+			err = awayTeam.updateDynamicAttributes(fixture, rng)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The current round is the last one of the leading sequence of fully played rounds.
+	// Fixtures already played in later rounds (e.g. brought forward) are kept, and the rest of their round is simulated later.
+	season.schedule.currentRoundIdx = -1
+	for _, round := range season.schedule.rounds {
+		if !round.allFixturesPlayed() {
+			break
+		}
+		season.schedule.currentRoundIdx += 1
+	}
+
+	season.schedule.nextRoundIdx = season.schedule.currentRoundIdx + 1
+	season.schedule.finished = false
+	if season.schedule.nextRoundIdx == len(season.schedule.rounds) {
+		season.schedule.nextRoundIdx = -1
+		season.schedule.finished = true
+	}
+
+	return season, nil
+}
@@ -0,0 +1,113 @@
+package simulation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestFixturesLoad(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "fixtures.csv")
+	err := os.WriteFile(filePath, []byte("round,home,away,home score,away score\n1, A, B, 2, 1\n1,C,D,,\n2,B,C,0,0\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures, err := fixturesLoad(filePath)
+	if err != nil {
+		t.Fatalf("unable to load fixtures: %v", err)
+	}
+	expected := []ImportedFixture{
+		{Round: 1, HomeTeam: "A", AwayTeam: "B", HomeTeamScore: 2, AwayTeamScore: 1, Played: true},
+		{Round: 1, HomeTeam: "C", AwayTeam: "D"},
+		{Round: 2, HomeTeam: "B", AwayTeam: "C", HomeTeamScore: 0, AwayTeamScore: 0, Played: true},
+	}
+	if !reflect.DeepEqual(fixtures, expected) {
+		t.Errorf("fixtures are %+v, expected %+v", fixtures, expected)
+	}
+
+	for _, invalid := range []string{"1,A,B,2,\n", "1,A,B,-1,0\n", "1,A,B,2,1\nx,C,D,,\n", "1,A,B\n"} {
+		err := os.WriteFile(filePath, []byte(invalid), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fixturesLoad(filePath); err == nil {
+			t.Errorf("fixtures %q were accepted", invalid)
+		}
+	}
+}
+
+func newTestTeams(names ...string) []*Team {
+	teams := []*Team{}
+	for _, name := range names {
+		teams = append(teams, &Team{Name: name, Attack: 5, Midfield: 5, Defense: 5, HomeFactor: 5})
+	}
+	return teams
+}
+
+// Played fixtures count in the standings and the dynamic attributes, and only the others are simulated
+func TestNewSeasonFromImportedFixtures(t *testing.T) {
+	importedFixtures := []ImportedFixture{
+		{Round: 1, HomeTeam: "A", AwayTeam: "B", HomeTeamScore: 3, AwayTeamScore: 0, Played: true},
+		{Round: 1, HomeTeam: "C", AwayTeam: "D", HomeTeamScore: 1, AwayTeamScore: 1, Played: true},
+		{Round: 2, HomeTeam: "B", AwayTeam: "C", HomeTeamScore: 2, AwayTeamScore: 0, Played: true},
+		{Round: 2, HomeTeam: "D", AwayTeam: "A"},
+		{Round: 3, HomeTeam: "A", AwayTeam: "C"},
+		{Round: 3, HomeTeam: "B", AwayTeam: "D"},
+	}
+
+	season, err := newSeasonFromImportedFixtures(newTestTeams("A", "B", "C", "D"), importedFixtures, util.NewRng(1))
+	if err != nil {
+		t.Fatalf("unable to import fixtures: %v", err)
+	}
+
+	if season.schedule.currentRoundIdx != 0 || season.schedule.nextRoundIdx != 1 || season.schedule.finished {
+		t.Errorf("schedule is at current round index %d, next round index %d, expected 0 and 1", season.schedule.currentRoundIdx, season.schedule.nextRoundIdx)
+	}
+	if len(season.teams["B"].DynamicAttributes.LastFixtures) != 2 || len(season.teams["D"].DynamicAttributes.LastFixtures) != 1 {
+		t.Errorf("played fixtures were not replayed through the dynamic attributes")
+	}
+	if season.teams["A"].DynamicAttributes.Elo <= season.teams["D"].DynamicAttributes.Elo {
+		t.Errorf("A won 3-0 but its Elo rating %.1f is not above the one of D %.1f, who drew", season.teams["A"].DynamicAttributes.Elo, season.teams["D"].DynamicAttributes.Elo)
+	}
+
+	err = season.playAllFixtures()
+	if err != nil {
+		t.Fatalf("unable to play the remaining fixtures: %v", err)
+	}
+	for i, importedFixture := range importedFixtures[:3] {
+		fixture := season.schedule.rounds[importedFixture.Round-1].fixtures[i%2]
+		if fixture.homeTeamScore != importedFixture.HomeTeamScore || fixture.awayTeamScore != importedFixture.AwayTeamScore {
+			t.Errorf("round %d: %s %d x %d %s was replaced by %d x %d", importedFixture.Round, importedFixture.HomeTeam, importedFixture.HomeTeamScore,
+				importedFixture.AwayTeamScore, importedFixture.AwayTeam, fixture.homeTeamScore, fixture.awayTeamScore)
+		}
+	}
+	for i, teamStatistic := range season.standingsGenerate().TeamStatistics {
+		if teamStatistic.Matches != 3 {
+			t.Errorf("position %d: %s played %d matches, expected 3", i+1, teamStatistic.Name, teamStatistic.Matches)
+		}
+	}
+}
+
+func TestInvalidImportedFixturesAreRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures []ImportedFixture
+	}{
+		{"unknown team", []ImportedFixture{{Round: 1, HomeTeam: "A", AwayTeam: "E"}}},
+		{"team against itself", []ImportedFixture{{Round: 1, HomeTeam: "A", AwayTeam: "A"}}},
+		{"team twice in a round", []ImportedFixture{{Round: 1, HomeTeam: "A", AwayTeam: "B"}, {Round: 1, HomeTeam: "C", AwayTeam: "A"}}},
+		{"duplicate fixture", []ImportedFixture{{Round: 1, HomeTeam: "A", AwayTeam: "B"}, {Round: 2, HomeTeam: "A", AwayTeam: "B"}}},
+		{"missing round", []ImportedFixture{{Round: 1, HomeTeam: "A", AwayTeam: "B"}, {Round: 3, HomeTeam: "B", AwayTeam: "A"}}},
+		{"no fixtures", []ImportedFixture{}},
+	}
+
+	for _, test := range tests {
+		if _, err := newSeasonFromImportedFixtures(newTestTeams("A", "B", "C", "D"), test.fixtures, util.NewRng(1)); err == nil {
+			t.Errorf("%s: fixtures were accepted", test.name)
+		}
+	}
+}
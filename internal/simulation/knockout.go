@@ -0,0 +1,236 @@
+package simulation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Probability of converting a penalty kick with neutral morale
+	PENALTY_BASE_CONVERSION = 0.75
+	// Impact of the morale on the penalty conversion (see util.GetMultiplierFromContributionFactor)
+	PENALTY_MORALE_CONTRIBUTION_IMPACT = 0.02
+	// Number of kicks taken by each team before the sudden death
+	PENALTY_SHOOTOUT_KICKS = 5
+)
+
+// A knockout tie between two teams, played over one or two legs.
+// The first team plays at home in the first leg.
+type KnockoutTie struct {
+	firstTeam  string
+	secondTeam string
+	legs       []*Fixture
+	// Single-match ties, such as the Libertadores final, may be played at a neutral venue
+	neutralVenue bool
+	// If the first team advances without playing (secondTeam is empty)
+	bye                 bool
+	firstTeamPenalties  int
+	secondTeamPenalties int
+	decidedByPenalties  bool
+	winner              string
+}
+
+type KnockoutStage struct {
+	name    string
+	numLegs int
+	ties    []*KnockoutTie
+}
+
+func newKnockoutTie(firstTeam string, secondTeam string, numLegs int) *KnockoutTie {
+	tie := KnockoutTie{firstTeam: firstTeam, secondTeam: secondTeam}
+
+	tie.legs = append(tie.legs, newFixture(firstTeam, secondTeam))
+	if numLegs == 2 {
+		tie.legs = append(tie.legs, newFixture(secondTeam, firstTeam))
+	}
+
+	return &tie
+}
+
+func newKnockoutBye(team string) *KnockoutTie {
+	return &KnockoutTie{firstTeam: team, bye: true, winner: team}
+}
+
+// Plays the received leg of all ties of the stage
+func (s *KnockoutStage) playLeg(legIdx int, kickoff time.Time, teams map[string]*Team, matchModel MatchModel, rng *util.Rng, events *EventEmitter, competition string) error {
+	for _, tie := range s.ties {
+		if tie.bye {
+			continue
+		}
+
+		context := FixtureContext{Competition: competition, Stage: s.name, RoundIdx: legIdx, NeutralVenue: tie.neutralVenue}
+		leg := tie.legs[legIdx]
+		leg.kickoff = kickoff
+		err := playFixture(leg, teams[leg.homeTeam], teams[leg.awayTeam], matchModel, rng, events, context)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decides all ties of the stage, once all legs were played
+func (s *KnockoutStage) resolve(teams map[string]*Team, rng *util.Rng) {
+	for _, tie := range s.ties {
+		if !tie.bye {
+			tie.resolve(teams[tie.firstTeam], teams[tie.secondTeam], rng)
+		}
+	}
+}
+
+// Returns the goals scored by the first and the second team over all played legs
+func (t *KnockoutTie) aggregate() (int, int) {
+	firstTeamGoals := 0
+	secondTeamGoals := 0
+
+	for _, leg := range t.legs {
+		if !leg.played {
+			continue
+		}
+		if leg.homeTeam == t.firstTeam {
+			firstTeamGoals += leg.homeTeamScore
+			secondTeamGoals += leg.awayTeamScore
+		} else {
+			firstTeamGoals += leg.awayTeamScore
+			secondTeamGoals += leg.homeTeamScore
+		}
+	}
+
+	return firstTeamGoals, secondTeamGoals
+}
+
+// Decides the winner once all legs were played, going to a penalty shootout if the aggregate score is level
+func (t *KnockoutTie) resolve(firstTeam *Team, secondTeam *Team, rng *util.Rng) {
+	firstTeamGoals, secondTeamGoals := t.aggregate()
+
+	if firstTeamGoals > secondTeamGoals {
+		t.winner = t.firstTeam
+	} else if secondTeamGoals > firstTeamGoals {
+		t.winner = t.secondTeam
+	} else {
+		t.firstTeamPenalties, t.secondTeamPenalties = penaltyShootout(firstTeam, secondTeam, rng)
+		t.decidedByPenalties = true
+		if t.firstTeamPenalties > t.secondTeamPenalties {
+			t.winner = t.firstTeam
+		} else {
+			t.winner = t.secondTeam
+		}
+	}
+}
+
+// Returns the penalties scored by each team. The first team always kicks first.
+func penaltyShootout(firstTeam *Team, secondTeam *Team, rng *util.Rng) (int, int) {
+	firstTeamProbability := penaltyConversionProbability(firstTeam)
+	secondTeamProbability := penaltyConversionProbability(secondTeam)
+
+	firstTeamScore := 0
+	secondTeamScore := 0
+
+	for kick := 0; kick < PENALTY_SHOOTOUT_KICKS; kick++ {
+		if rng.Float64() < firstTeamProbability {
+			firstTeamScore += 1
+		}
+		if penaltyShootoutDecided(firstTeamScore, secondTeamScore, kick+1, kick) {
+			return firstTeamScore, secondTeamScore
+		}
+
+		if rng.Float64() < secondTeamProbability {
+			secondTeamScore += 1
+		}
+		if penaltyShootoutDecided(firstTeamScore, secondTeamScore, kick+1, kick+1) {
+			return firstTeamScore, secondTeamScore
+		}
+	}
+
+	// Sudden death
+	for firstTeamScore == secondTeamScore {
+		if rng.Float64() < firstTeamProbability {
+			firstTeamScore += 1
+		}
+		if rng.Float64() < secondTeamProbability {
+			secondTeamScore += 1
+		}
+	}
+
+	return firstTeamScore, secondTeamScore
+}
+
+// Whether one of the teams can no longer catch up within the regular kicks
+func penaltyShootoutDecided(firstTeamScore int, secondTeamScore int, firstTeamKicks int, secondTeamKicks int) bool {
+	firstTeamRemaining := PENALTY_SHOOTOUT_KICKS - firstTeamKicks
+	secondTeamRemaining := PENALTY_SHOOTOUT_KICKS - secondTeamKicks
+	return firstTeamScore > secondTeamScore+secondTeamRemaining || secondTeamScore > firstTeamScore+firstTeamRemaining
+}
+
+func penaltyConversionProbability(t *Team) float64 {
+	multiplier := util.GetMultiplierFromContributionFactor(t.DynamicAttributes.Morale, PENALTY_MORALE_CONTRIBUTION_IMPACT)
+	return util.Clamp(PENALTY_BASE_CONVERSION*multiplier, 0, 1)
+}
+
+func (t *KnockoutTie) print() {
+	if t.bye {
+		fmt.Printf("\t%s advances (bye)\n", t.firstTeam)
+		return
+	}
+
+	legs := ""
+	for i, leg := range t.legs {
+		if i > 0 {
+			legs += " | "
+		}
+		if leg.played {
+			legs += fmt.Sprintf("%s %d x %d %s", leg.homeTeam, leg.homeTeamScore, leg.awayTeamScore, leg.awayTeam)
+		} else {
+			legs += fmt.Sprintf("%s x %s", leg.homeTeam, leg.awayTeam)
+		}
+	}
+
+	result := ""
+	if t.neutralVenue {
+		result += " (neutral venue)"
+	}
+	if len(t.legs) > 1 {
+		firstTeamGoals, secondTeamGoals := t.aggregate()
+		result += fmt.Sprintf(" (agg. %d-%d)", firstTeamGoals, secondTeamGoals)
+	}
+	if t.decidedByPenalties {
+		result += fmt.Sprintf(" (pens. %d-%d)", t.firstTeamPenalties, t.secondTeamPenalties)
+	}
+	if t.winner != "" {
+		result += fmt.Sprintf(" → %s", t.winner)
+	}
+
+	fmt.Printf("\t%s%s\n", legs, result)
+}
+
+func (s *KnockoutStage) print() {
+	fmt.Printf("%s\n", s.name)
+	for _, tie := range s.ties {
+		tie.print()
+	}
+}
+
+func (s *KnockoutStage) winners() []string {
+	winners := make([]string, 0, len(s.ties))
+	for _, tie := range s.ties {
+		winners = append(winners, tie.winner)
+	}
+	return winners
+}
+
+// Name of a knockout stage given the number of teams in it
+func knockoutStageName(numTeams int) string {
+	switch numTeams {
+	case 2:
+		return "Final"
+	case 4:
+		return "Semi-finals"
+	case 8:
+		return "Quarter-finals"
+	default:
+		return fmt.Sprintf("Round of %d", numTeams)
+	}
+}
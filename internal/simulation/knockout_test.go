@@ -0,0 +1,150 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestKnockoutTieIsDecidedOnAggregate(t *testing.T) {
+	teams := map[string]*Team{}
+	for _, team := range newTestTeams("A", "B") {
+		teams[team.Name] = team
+	}
+
+	tie := newKnockoutTie("A", "B", 2)
+	if tie.legs[0].homeTeam != "A" || tie.legs[1].homeTeam != "B" {
+		t.Fatalf("legs are %s x %s and %s x %s, expected A at home first", tie.legs[0].homeTeam, tie.legs[0].awayTeam, tie.legs[1].homeTeam, tie.legs[1].awayTeam)
+	}
+
+	// A wins the first leg 2-0 and loses the second one 1-0, so it goes through 2-1 on aggregate
+	tie.legs[0].homeTeamScore, tie.legs[0].awayTeamScore, tie.legs[0].played = 2, 0, true
+	tie.legs[1].homeTeamScore, tie.legs[1].awayTeamScore, tie.legs[1].played = 1, 0, true
+	tie.resolve(teams["A"], teams["B"], util.NewRng(1))
+
+	if firstTeamGoals, secondTeamGoals := tie.aggregate(); firstTeamGoals != 2 || secondTeamGoals != 1 {
+		t.Errorf("aggregate is %d-%d, expected 2-1", firstTeamGoals, secondTeamGoals)
+	}
+	if tie.winner != "A" || tie.decidedByPenalties {
+		t.Errorf("winner is [%s] (penalties: %v), expected A on aggregate", tie.winner, tie.decidedByPenalties)
+	}
+}
+
+func TestLevelKnockoutTieGoesToPenalties(t *testing.T) {
+	teams := map[string]*Team{}
+	for _, team := range newTestTeams("A", "B") {
+		teams[team.Name] = team
+	}
+
+	for seed := uint64(1); seed <= 20; seed++ {
+		tie := newKnockoutTie("A", "B", 2)
+		tie.legs[0].homeTeamScore, tie.legs[0].awayTeamScore, tie.legs[0].played = 2, 1, true
+		tie.legs[1].homeTeamScore, tie.legs[1].awayTeamScore, tie.legs[1].played = 1, 0, true
+		tie.resolve(teams["A"], teams["B"], util.NewRng(seed))
+
+		if !tie.decidedByPenalties || tie.firstTeamPenalties == tie.secondTeamPenalties {
+			t.Fatalf("seed %d: level tie was not decided by penalties (%d-%d)", seed, tie.firstTeamPenalties, tie.secondTeamPenalties)
+		}
+		expectedWinner := "A"
+		if tie.secondTeamPenalties > tie.firstTeamPenalties {
+			expectedWinner = "B"
+		}
+		if tie.winner != expectedWinner {
+			t.Errorf("seed %d: winner is [%s] after penalties %d-%d", seed, tie.winner, tie.firstTeamPenalties, tie.secondTeamPenalties)
+		}
+	}
+}
+
+func TestPenaltyShootoutDecided(t *testing.T) {
+	tests := []struct {
+		firstTeamScore  int
+		secondTeamScore int
+		firstTeamKicks  int
+		secondTeamKicks int
+		expected        bool
+	}{
+		{0, 0, 1, 0, false},
+		{3, 0, 3, 3, true},
+		{3, 1, 3, 3, false},
+		{3, 0, 3, 2, false},
+		{4, 2, 4, 4, true},
+		{5, 4, 5, 4, false},
+		{5, 4, 5, 5, true},
+		{4, 4, 5, 5, false},
+	}
+
+	for _, test := range tests {
+		if decided := penaltyShootoutDecided(test.firstTeamScore, test.secondTeamScore, test.firstTeamKicks, test.secondTeamKicks); decided != test.expected {
+			t.Errorf("%d-%d after %d and %d kicks: decided is %v, expected %v", test.firstTeamScore, test.secondTeamScore,
+				test.firstTeamKicks, test.secondTeamKicks, decided, test.expected)
+		}
+	}
+}
+
+func TestPenaltyShootout(t *testing.T) {
+	teams := newTestTeams("A", "B")
+	rng := util.NewRng(3)
+
+	for i := 0; i < 1000; i++ {
+		firstTeamScore, secondTeamScore := penaltyShootout(teams[0], teams[1], rng)
+		if firstTeamScore == secondTeamScore {
+			t.Fatalf("shootout ended level %d-%d", firstTeamScore, secondTeamScore)
+		}
+		// The sudden death ends as soon as one team scores and the other one misses
+		if max(firstTeamScore, secondTeamScore) > PENALTY_SHOOTOUT_KICKS && util.IntAbs(firstTeamScore-secondTeamScore) != 1 {
+			t.Errorf("shootout ended %d-%d in the sudden death", firstTeamScore, secondTeamScore)
+		}
+	}
+
+	teams[0].DynamicAttributes.Morale = 10
+	teams[1].DynamicAttributes.Morale = 0
+	if penaltyConversionProbability(teams[0]) <= penaltyConversionProbability(teams[1]) {
+		t.Errorf("conversion with the best morale %.3f is not above the one with the worst morale %.3f",
+			penaltyConversionProbability(teams[0]), penaltyConversionProbability(teams[1]))
+	}
+}
+
+// With 20 teams, 8 of them play a preliminary round, so the next stage has 16 teams
+func TestCupPlaysUntilOneChampion(t *testing.T) {
+	season, err := newSeason(loadTestTeams(t), util.NewRng(1))
+	if err != nil {
+		t.Fatalf("unable to create season: %v", err)
+	}
+	cup, err := newCup(COPA_DO_BRASIL_NAME, season)
+	if err != nil {
+		t.Fatalf("unable to create the cup: %v", err)
+	}
+
+	preliminaryRound := cup.currentStage()
+	byes := 0
+	for _, tie := range preliminaryRound.ties {
+		if tie.bye {
+			byes += 1
+		}
+	}
+	if preliminaryRound.name != "Preliminary round" || len(preliminaryRound.ties)-byes != 4 || byes != 12 {
+		t.Errorf("first stage is [%s] with %d ties and %d byes, expected a preliminary round with 4 ties and 12 byes",
+			preliminaryRound.name, len(preliminaryRound.ties)-byes, byes)
+	}
+
+	for !cup.finished() {
+		err := cup.playNextLeg(time.Time{})
+		if err != nil {
+			t.Fatalf("unable to play leg: %v", err)
+		}
+	}
+
+	if cup.playedLegs != cupNumStages(20)*COPA_DO_BRASIL_LEGS {
+		t.Errorf("%d legs were played, expected %d", cup.playedLegs, cupNumStages(20)*COPA_DO_BRASIL_LEGS)
+	}
+	expectedStages := []string{"Preliminary round", "Round of 16", "Quarter-finals", "Semi-finals", "Final"}
+	for i, stage := range cup.stages {
+		if stage.name != expectedStages[i] {
+			t.Errorf("stage %d is [%s], expected [%s]", i+1, stage.name, expectedStages[i])
+		}
+	}
+	if season.teamsGetWithName(cup.champion) == nil || cup.champion != cup.stages[len(cup.stages)-1].ties[0].winner {
+		t.Errorf("champion [%s] is not the winner of the final", cup.champion)
+	}
+}
@@ -0,0 +1,423 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	COPA_LIBERTADORES_NAME  = "Copa Libertadores"
+	LIBERTADORES_NUM_GROUPS = 8
+	LIBERTADORES_GROUP_SIZE = 4
+	// Ties of the round of 16, quarter-finals and semi-finals. The final is a single match.
+	LIBERTADORES_KNOCKOUT_LEGS = 2
+	// Group winners and runners-up are seeded by their group stage campaign. Head-to-head is meaningless across groups.
+	LIBERTADORES_SEEDING_TIE_BREAKERS = "points,goal-difference,goals-for,drawing-of-lots"
+)
+
+var libertadoresGroupZones = []Zone{
+	{"Round of 16", 1, 2, "cyan", "Qualified to the round of 16"},
+	{"Sudamericana", 3, 3, "yellow", "Transferred to the Copa Sudamericana"},
+}
+
+// The Copa Libertadores, played alongside the league by its strongest clubs and by foreign clubs.
+// Brazilian clubs are shared with the league, so their Libertadores matches affect their morale and physical condition.
+type Libertadores struct {
+	teams      map[string]*Team
+	rng        *util.Rng
+	matchModel MatchModel
+	events     *EventEmitter
+	// Each group is a small season, so its standings follow the same tie-break chain as the league
+	groups []*Season
+	// Used to rank teams of different groups when seeding the knockout stage
+	seeding *Season
+	stages  []*KnockoutStage
+	// Knockout seed of each team that left the group stage (1 is the best group winner)
+	seeds map[string]int
+	// Leg of the current knockout stage that will be played next
+	nextLegIdx int
+	// League round after which each matchday is played
+	matchdaysSchedule []int
+	playedMatchdays   int
+	champion          string
+	// Matchdays played after the last league round, so they can be printed with it
+	lastPlayedMatchdays []LibertadoresMatchday
+}
+
+// Either a round of the group stage or a leg of a knockout stage
+type LibertadoresMatchday struct {
+	// -1 for knockout legs
+	groupRoundIdx int
+	stage         *KnockoutStage
+	legIdx        int
+	kickoff       time.Time
+}
+
+// Creates the Libertadores, drawing its groups. The foreign clubs are loaded from foreignTeamsPath,
+// and the remaining spots are taken by the league clubs with the best rating.
+func newLibertadores(season *Season, foreignTeamsPath string) (*Libertadores, error) {
+	foreignTeams, err := teamsLoad(foreignTeamsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	numTeams := LIBERTADORES_NUM_GROUPS * LIBERTADORES_GROUP_SIZE
+	numBrazilianTeams := numTeams - len(foreignTeams)
+	if numBrazilianTeams < 1 || numBrazilianTeams > len(season.teams) {
+		return nil, fmt.Errorf("the %s has %d spots, but [%s] has %d clubs and the league has %d", COPA_LIBERTADORES_NAME,
+			numTeams, foreignTeamsPath, len(foreignTeams), len(season.teams))
+	}
+
+	libertadores := Libertadores{
+		teams:      make(map[string]*Team),
+		rng:        season.rng,
+		matchModel: season.matchModel,
+		events:     season.events,
+	}
+
+	leagueNames := season.teamsGetAllNames()
+	sort.SliceStable(leagueNames, func(i, j int) bool {
+		return season.teams[leagueNames[i]].rating() > season.teams[leagueNames[j]].rating()
+	})
+	for _, name := range leagueNames[:numBrazilianTeams] {
+		libertadores.teams[name] = season.teams[name]
+	}
+
+	for _, team := range foreignTeams {
+		if _, ok := libertadores.teams[team.Name]; ok {
+			return nil, fmt.Errorf("club [%s] appears more than once in the %s", team.Name, COPA_LIBERTADORES_NAME)
+		}
+		libertadores.teams[team.Name] = team
+	}
+
+	libertadores.seeding = &Season{teams: libertadores.teams, rng: libertadores.rng}
+	libertadores.seeding.tieBreakChain, err = tieBreakChainParse(LIBERTADORES_SEEDING_TIE_BREAKERS)
+	if err != nil {
+		return nil, err
+	}
+	libertadores.seeding.drawLots()
+
+	groups, err := libertadores.drawGroups(libertadores.pots())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, groupTeamNames := range groups {
+		group := Season{
+			teams:         make(map[string]*Team),
+			rng:           libertadores.rng,
+			tieBreakChain: season.tieBreakChain,
+			zones:         libertadoresGroupZones,
+		}
+		for _, name := range groupTeamNames {
+			group.teams[name] = libertadores.teams[name]
+		}
+
+		group.schedule, err = generateSchedule(group.teamsGetAllNames(), libertadores.rng)
+		if err != nil {
+			return nil, err
+		}
+		group.drawLots()
+
+		libertadores.groups = append(libertadores.groups, &group)
+	}
+
+	numGroupRounds := len(libertadores.groups[0].schedule.rounds)
+	numKnockoutMatchdays := (libertadoresNumKnockoutStages()-1)*LIBERTADORES_KNOCKOUT_LEGS + 1
+	libertadores.matchdaysSchedule = spreadMatchdaysOverLeague(len(season.schedule.rounds), numGroupRounds+numKnockoutMatchdays)
+
+	return &libertadores, nil
+}
+
+// Number of knockout stages, from the round of 16 to the final
+func libertadoresNumKnockoutStages() int {
+	return cupNumStages(2 * LIBERTADORES_NUM_GROUPS)
+}
+
+// Splits the teams in pots of LIBERTADORES_NUM_GROUPS teams, from the best to the worst rated
+func (l *Libertadores) pots() [][]string {
+	names := make([]string, 0, len(l.teams))
+	for name := range l.teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sort.SliceStable(names, func(i, j int) bool {
+		return l.teams[names[i]].rating() > l.teams[names[j]].rating()
+	})
+
+	pots := [][]string{}
+	for i := 0; i < len(names); i += LIBERTADORES_NUM_GROUPS {
+		pots = append(pots, names[i:i+LIBERTADORES_NUM_GROUPS])
+	}
+	return pots
+}
+
+// Draws one team of each pot into each group, so that clubs from the same country never share a group.
+// As in the real draw, each drawn team goes to the first group that can receive it, as long as the
+// remaining teams of its pot and of the later pots can still be placed.
+func (l *Libertadores) drawGroups(pots [][]string) ([][]string, error) {
+	// No draw can place more clubs of a country than there are groups
+	countryCounts := make(map[string]int)
+	for _, pot := range pots {
+		for _, name := range pot {
+			country := l.teams[name].country()
+			countryCounts[country] += 1
+			if countryCounts[country] > LIBERTADORES_NUM_GROUPS {
+				return nil, fmt.Errorf("more than %d clubs from [%s] can't be drawn without clubs of the same country in a group", LIBERTADORES_NUM_GROUPS, country)
+			}
+		}
+	}
+
+	drawnPots := make([][]string, 0, len(pots))
+	for _, pot := range pots {
+		drawn := make([]string, 0, len(pot))
+		for _, i := range l.rng.Perm(len(pot)) {
+			drawn = append(drawn, pot[i])
+		}
+		drawnPots = append(drawnPots, drawn)
+	}
+
+	groups := make([][]string, LIBERTADORES_NUM_GROUPS)
+	if !l.placeDrawnTeams(groups, drawnPots, 0) {
+		return nil, fmt.Errorf("unable to draw the groups without clubs of the same country in a group")
+	}
+
+	return groups, nil
+}
+
+// Places the drawn teams of the pot potIdx and of the later pots. When a team can't be placed, the placements
+// of the previous teams are undone, including those of earlier pots.
+func (l *Libertadores) placeDrawnTeams(groups [][]string, drawnPots [][]string, potIdx int) bool {
+	if potIdx == len(drawnPots) {
+		return true
+	}
+
+	drawn := drawnPots[potIdx]
+	if len(drawn) == 0 {
+		return l.placeDrawnTeams(groups, drawnPots, potIdx+1)
+	}
+
+	team := l.teams[drawn[0]]
+	for i := range groups {
+		if len(groups[i]) > potIdx || l.groupHasCountry(groups[i], team.country()) {
+			continue
+		}
+
+		groups[i] = append(groups[i], team.Name)
+		drawnPots[potIdx] = drawn[1:]
+		if l.placeDrawnTeams(groups, drawnPots, potIdx) {
+			return true
+		}
+		drawnPots[potIdx] = drawn
+		groups[i] = groups[i][:len(groups[i])-1]
+	}
+
+	return false
+}
+
+func (l *Libertadores) groupHasCountry(group []string, country string) bool {
+	for _, name := range group {
+		if l.teams[name].country() == country {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Libertadores) groupStageFinished() bool {
+	return l.groups[0].schedule.finished
+}
+
+func (l *Libertadores) finished() bool {
+	return l.champion != ""
+}
+
+// Plays the next matchday, at the received kickoff, if it is scheduled after the received league round or before it.
+// Only one matchday is played per midweek. When the league is finished, all remaining matchdays are played, one per week.
+func (l *Libertadores) playMatchdaysScheduledAfterRound(roundIdx int, leagueFinished bool, kickoff time.Time) error {
+	l.lastPlayedMatchdays = nil
+
+	for !l.finished() && (leagueFinished || (l.playedMatchdays < len(l.matchdaysSchedule) && l.matchdaysSchedule[l.playedMatchdays] <= roundIdx)) {
+		var err error
+		if !l.groupStageFinished() {
+			err = l.playNextGroupRound(kickoff)
+		} else {
+			err = l.playNextKnockoutLeg(kickoff)
+		}
+		if err != nil {
+			return err
+		}
+		l.playedMatchdays += 1
+		if !leagueFinished {
+			break
+		}
+		kickoff = addDays(kickoff, 7)
+	}
+
+	return nil
+}
+
+func (l *Libertadores) playNextGroupRound(kickoff time.Time) error {
+	roundIdx := l.groups[0].schedule.nextRoundIdx
+
+	for i, group := range l.groups {
+		context := FixtureContext{Competition: COPA_LIBERTADORES_NAME, Stage: groupName(i), RoundIdx: roundIdx}
+		for _, fixture := range group.schedule.rounds[roundIdx].fixtures {
+			fixture.kickoff = kickoff
+			err := playFixture(fixture, l.teams[fixture.homeTeam], l.teams[fixture.awayTeam], l.matchModel, l.rng, l.events, context)
+			if err != nil {
+				return err
+			}
+		}
+		group.schedule.advance()
+	}
+
+	l.lastPlayedMatchdays = append(l.lastPlayedMatchdays, LibertadoresMatchday{groupRoundIdx: roundIdx, kickoff: kickoff})
+
+	if l.groupStageFinished() {
+		l.drawKnockoutStage()
+	}
+
+	return nil
+}
+
+// Seeds the group winners ahead of the runners-up, ranking each of them by their group stage campaign,
+// and pairs them in a fixed bracket in which the best seeds can only meet in the late stages
+func (l *Libertadores) drawKnockoutStage() {
+	winners := []*TeamStatistic{}
+	runnersUp := []*TeamStatistic{}
+	for _, group := range l.groups {
+		standings := group.standingsGenerate()
+		winners = append(winners, standings.TeamStatistics[0])
+		runnersUp = append(runnersUp, standings.TeamStatistics[1])
+	}
+
+	l.seeds = make(map[string]int)
+	for _, qualified := range [][]*TeamStatistic{winners, runnersUp} {
+		// Teams of different groups never played each other, so no round is considered
+		l.seeding.rankTeamStatistics(qualified, -1)
+		for _, teamStatistic := range qualified {
+			l.seeds[teamStatistic.Name] = len(l.seeds) + 1
+		}
+	}
+
+	bracketTeams := make([]string, len(l.seeds))
+	for name, seed := range l.seeds {
+		bracketTeams[bracketPosition(seed, len(l.seeds))] = name
+	}
+
+	l.addKnockoutStage(bracketTeams)
+}
+
+// Position of a seed in a bracket of numTeams teams, so that seed 1 meets seed numTeams in the first stage,
+// and the two best seeds can only meet in the final
+func bracketPosition(seed int, numTeams int) int {
+	order := []int{1}
+	for len(order) < numTeams {
+		nextOrder := make([]int, 0, 2*len(order))
+		for _, s := range order {
+			nextOrder = append(nextOrder, s, 2*len(order)+1-s)
+		}
+		order = nextOrder
+	}
+
+	for i, s := range order {
+		if s == seed {
+			return i
+		}
+	}
+	return -1
+}
+
+// Pairs adjacent teams of the bracket. The best seed of each tie plays the second leg at home,
+// and the final is a single match at a neutral venue.
+func (l *Libertadores) addKnockoutStage(bracketTeams []string) {
+	numLegs := LIBERTADORES_KNOCKOUT_LEGS
+	if len(bracketTeams) == 2 {
+		numLegs = 1
+	}
+
+	stage := KnockoutStage{name: knockoutStageName(len(bracketTeams)), numLegs: numLegs}
+	for i := 0; i < len(bracketTeams); i += 2 {
+		bestSeed := bracketTeams[i]
+		worstSeed := bracketTeams[i+1]
+		if l.seeds[worstSeed] < l.seeds[bestSeed] {
+			bestSeed, worstSeed = worstSeed, bestSeed
+		}
+
+		tie := newKnockoutTie(worstSeed, bestSeed, numLegs)
+		tie.neutralVenue = numLegs == 1
+		stage.ties = append(stage.ties, tie)
+	}
+
+	l.stages = append(l.stages, &stage)
+	l.nextLegIdx = 0
+}
+
+func (l *Libertadores) playNextKnockoutLeg(kickoff time.Time) error {
+	stage := l.stages[len(l.stages)-1]
+	legIdx := l.nextLegIdx
+
+	err := stage.playLeg(legIdx, kickoff, l.teams, l.matchModel, l.rng, l.events, COPA_LIBERTADORES_NAME)
+	if err != nil {
+		return err
+	}
+
+	l.lastPlayedMatchdays = append(l.lastPlayedMatchdays, LibertadoresMatchday{groupRoundIdx: -1, stage: stage, legIdx: legIdx, kickoff: kickoff})
+	l.nextLegIdx += 1
+
+	if l.nextLegIdx < stage.numLegs {
+		return nil
+	}
+
+	stage.resolve(l.teams, l.rng)
+
+	winners := stage.winners()
+	if len(winners) == 1 {
+		l.champion = winners[0]
+	} else {
+		l.addKnockoutStage(winners)
+	}
+
+	return nil
+}
+
+// Prints the matchdays played after the last league round
+func (l *Libertadores) printLastPlayedMatchdays() {
+	for _, matchday := range l.lastPlayedMatchdays {
+		if matchday.groupRoundIdx >= 0 {
+			fmt.Printf("%s\n", formatMatchdayDescription(COPA_LIBERTADORES_NAME, fmt.Sprintf("Group stage (matchday %d)", matchday.groupRoundIdx+1), matchday.kickoff))
+			for i, group := range l.groups {
+				for _, fixture := range group.schedule.rounds[matchday.groupRoundIdx].fixtures {
+					fmt.Printf("\t%s: %s %d x %d %s\n", groupName(i), fixture.homeTeam, fixture.homeTeamScore, fixture.awayTeamScore, fixture.awayTeam)
+				}
+			}
+		} else {
+			fmt.Printf("%s\n", formatMatchdayDescription(COPA_LIBERTADORES_NAME, fmt.Sprintf("%s (leg %d)", matchday.stage.name, matchday.legIdx+1), matchday.kickoff))
+			matchday.stage.print()
+		}
+	}
+}
+
+// Prints the group standings and all knockout stages played so far
+func (l *Libertadores) printSummary(enableTerminalColors bool) {
+	fmt.Println()
+	fmt.Printf("%s\n", COPA_LIBERTADORES_NAME)
+
+	for i, group := range l.groups {
+		fmt.Printf("%s\n", groupName(i))
+		printGroupStandings(group.standingsGenerate().TeamStatistics, l.teams, libertadoresGroupZones, enableTerminalColors)
+	}
+	printZonesLegend(libertadoresGroupZones, enableTerminalColors)
+
+	for _, stage := range l.stages {
+		stage.print()
+	}
+
+	if l.finished() {
+		fmt.Printf("%s champion: [%s]\n", COPA_LIBERTADORES_NAME, l.champion)
+	}
+}
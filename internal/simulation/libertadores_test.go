@@ -0,0 +1,108 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestBracketPosition(t *testing.T) {
+	for _, numTeams := range []int{2, 4, 8, 16} {
+		seedsAtPosition := make([]int, numTeams)
+		for seed := 1; seed <= numTeams; seed++ {
+			position := bracketPosition(seed, numTeams)
+			if position < 0 || position >= numTeams || seedsAtPosition[position] != 0 {
+				t.Fatalf("%d teams: seed %d has invalid or repeated position %d", numTeams, seed, position)
+			}
+			seedsAtPosition[position] = seed
+		}
+
+		// Adjacent positions meet in the first stage: the best seed against the worst one, and so on
+		for position := 0; position < numTeams; position += 2 {
+			if seedsAtPosition[position]+seedsAtPosition[position+1] != numTeams+1 {
+				t.Errorf("%d teams: seeds %d and %d meet in the first stage", numTeams, seedsAtPosition[position], seedsAtPosition[position+1])
+			}
+		}
+
+		// The two best seeds are in different halves, so they can only meet in the final
+		if numTeams > 2 && (bracketPosition(1, numTeams) < numTeams/2) == (bracketPosition(2, numTeams) < numTeams/2) {
+			t.Errorf("%d teams: seeds 1 and 2 are in the same half", numTeams)
+		}
+	}
+}
+
+// Teams of the received countries, one pot of LIBERTADORES_NUM_GROUPS teams per row
+func newTestLibertadores(potsCountries [][]string, seed uint64) (*Libertadores, [][]string) {
+	libertadores := &Libertadores{teams: make(map[string]*Team), rng: util.NewRng(seed)}
+	pots := [][]string{}
+	for potIdx, potCountries := range potsCountries {
+		pot := []string{}
+		for i, country := range potCountries {
+			name := fmt.Sprintf("%s %d-%d", country, potIdx+1, i+1)
+			libertadores.teams[name] = &Team{Name: name, Country: country}
+			pot = append(pot, name)
+		}
+		pots = append(pots, pot)
+	}
+	return libertadores, pots
+}
+
+func TestDrawGroupsKeepsCountriesApart(t *testing.T) {
+	// Each country has one club in each pot, so every group must hold four different countries.
+	// Placing a pot without looking at the later ones often leaves no valid group for the last pot.
+	countries := []string{"Argentina", "Bolivia", "Brazil", "Chile", "Colombia", "Ecuador", "Paraguay", "Uruguay"}
+	potsCountries := [][]string{}
+	for potIdx := 0; potIdx < LIBERTADORES_GROUP_SIZE; potIdx++ {
+		potsCountries = append(potsCountries, countries)
+	}
+
+	for seed := uint64(1); seed <= 50; seed++ {
+		libertadores, pots := newTestLibertadores(potsCountries, seed)
+		groups, err := libertadores.drawGroups(pots)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		for groupIdx, group := range groups {
+			if len(group) != LIBERTADORES_GROUP_SIZE {
+				t.Fatalf("seed %d: group %d has %d teams", seed, groupIdx+1, len(group))
+			}
+			groupCountries := make(map[string]bool)
+			for potIdx, name := range group {
+				if indexOf(pots[potIdx], name) < 0 {
+					t.Errorf("seed %d: team [%s] of group %d is not from pot %d", seed, name, groupIdx+1, potIdx+1)
+				}
+				country := libertadores.teams[name].country()
+				if groupCountries[country] {
+					t.Errorf("seed %d: group %d has more than one club from [%s]", seed, groupIdx+1, country)
+				}
+				groupCountries[country] = true
+			}
+		}
+	}
+}
+
+func TestDrawGroupsRejectsTooManyClubsOfACountry(t *testing.T) {
+	others := []string{"Argentina", "Bolivia", "Chile", "Colombia", "Ecuador", "Paraguay", "Peru"}
+	potsCountries := [][]string{
+		{"Brazil", "Brazil", "Brazil", "Brazil", "Brazil", "Brazil", "Brazil", "Brazil"},
+		append([]string{"Brazil"}, others...),
+		append([]string{"Uruguay"}, others...),
+		append([]string{"Uruguay"}, others...),
+	}
+
+	libertadores, pots := newTestLibertadores(potsCountries, 1)
+	if _, err := libertadores.drawGroups(pots); err == nil {
+		t.Errorf("%d clubs from Brazil were drawn into %d groups", LIBERTADORES_NUM_GROUPS+1, LIBERTADORES_NUM_GROUPS)
+	}
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
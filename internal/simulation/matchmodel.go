@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	MATCH_MODEL_ATTRIBUTES = "attributes"
+)
+
+// Everything a match model knows about a match
+type MatchInput struct {
+	HomeTeam   *Team
+	AwayTeam   *Team
+	HomeLineup *Lineup
+	AwayLineup *Lineup
+	// Neither team has the home factor
+	NeutralVenue bool
+}
+
+type MatchOutcome struct {
+	HomeScore int
+	AwayScore int
+	// Intermediate values that explain the score, reported in the event stream
+	Strengths MatchStrengths
+	// Optional. If nil, the goals are attributed to the players of the starting XIs.
+	Goals []Goal
+}
+
+// Decides the score of a match. Fixture.play takes care of everything around it:
+// lineups, goal scorers, cards and the dynamic attributes of both teams.
+type MatchModel interface {
+	Name() string
+	playMatch(input MatchInput, rng *util.Rng) (MatchOutcome, error)
+}
+
+// The original model: strengths from the attributes, home factor, recent form, morale and physical condition,
+// attenuated into the expected goals from which the score model draws the score
+type AttributesMatchModel struct {
+	scoreModel ScoreModel
+}
+
+var matchModelNames = []string{MATCH_MODEL_ATTRIBUTES, MATCH_MODEL_ELO}
+
+// Creates the match model with the received name (the attributes model if empty), drawing scores with the received score model
+func matchModelCreate(name string, scoreModel ScoreModel) (MatchModel, error) {
+	err := matchModelValidate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == MATCH_MODEL_ELO {
+		return EloMatchModel{scoreModel: scoreModel}, nil
+	}
+	return AttributesMatchModel{scoreModel: scoreModel}, nil
+}
+
+func matchModelValidate(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, modelName := range matchModelNames {
+		if modelName == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown match model [%s] (available: %s)", name, strings.Join(matchModelNames, ", "))
+}
+
+func (m AttributesMatchModel) Name() string {
+	return MATCH_MODEL_ATTRIBUTES
+}
+
+func (m AttributesMatchModel) playMatch(input MatchInput, rng *util.Rng) (MatchOutcome, error) {
+	homeTeam := input.HomeTeam
+	awayTeam := input.AwayTeam
+
+	// Additional strength given to the home team (home factor)
+	homeStadiumStrength := homeFactorStrength(homeTeam.HomeFactor)
+	if input.NeutralVenue {
+		// Neither team has the home factor
+		homeStadiumStrength = 1.0
+	}
+
+	// Calculate home team recent form contribution
+	homeTeamRawFormContribution, err := calculateFormContribution(homeTeam.Name, homeTeam.DynamicAttributes.LastFixtures)
+	if err != nil {
+		return MatchOutcome{}, err
+	}
+	homeTeamFormContribution := util.GetMultiplierFromContributionFactor(homeTeamRawFormContribution, RECENT_FORM_CONTRIBUTION_IMPACT)
+
+	// Calculate away team recent form contribution
+	awayTeamRawFormContribution, err := calculateFormContribution(awayTeam.Name, awayTeam.DynamicAttributes.LastFixtures)
+	if err != nil {
+		return MatchOutcome{}, err
+	}
+	awayTeamFormContribution := util.GetMultiplierFromContributionFactor(awayTeamRawFormContribution, RECENT_FORM_CONTRIBUTION_IMPACT)
+
+	// Caclulate morale contribution
+	homeTeamMoraleContribution := util.GetMultiplierFromContributionFactor(homeTeam.DynamicAttributes.Morale, MORALE_CONTRIBUTION_IMPACT)
+	awayTeamMoraleContribution := util.GetMultiplierFromContributionFactor(awayTeam.DynamicAttributes.Morale, MORALE_CONTRIBUTION_IMPACT)
+
+	// Caclulate physical condition contribution
+	homeTeamPhysicalConditionContribution := util.GetMultiplierFromContributionFactor(homeTeam.DynamicAttributes.PhysicalCondition, PHYSICAL_CONDITION_CONTRIBUTION_IMPACT)
+	awayTeamPhysicalConditionContribution := util.GetMultiplierFromContributionFactor(awayTeam.DynamicAttributes.PhysicalCondition, PHYSICAL_CONDITION_CONTRIBUTION_IMPACT)
+
+	// Calculate home/away strength without other contributions
+	homeRawStrength := rawStrength(input.HomeLineup.attack, input.HomeLineup.midfield, input.AwayLineup.defense, input.AwayLineup.midfield)
+	awayRawStrength := rawStrength(input.AwayLineup.attack, input.AwayLineup.midfield, input.HomeLineup.defense, input.HomeLineup.midfield)
+
+	// Final non-attentuated strength of each team for this match
+	homeStrength := homeStadiumStrength * homeTeamFormContribution * homeTeamMoraleContribution * homeTeamPhysicalConditionContribution * homeRawStrength
+	awayStrength := awayTeamFormContribution * awayTeamMoraleContribution * awayTeamPhysicalConditionContribution * awayRawStrength
+
+	// Attenuate strengths by employing a log-based function
+	homeLambda := util.AttenuateStrength(homeStrength)
+	awayLambda := util.AttenuateStrength(awayStrength)
+
+	// Generate final scores based on a poisson distribution
+	homeScore, awayScore := m.scoreModel.sampleScore(homeLambda, awayLambda, rng)
+
+	return MatchOutcome{
+		HomeScore: homeScore,
+		AwayScore: awayScore,
+		Strengths: MatchStrengths{
+			HomeStrength: homeStrength,
+			AwayStrength: awayStrength,
+			HomeLambda:   homeLambda,
+			AwayLambda:   awayLambda,
+		},
+	}, nil
+}
+
+// Multiplier of the strength of the home team
+func homeFactorStrength(homeFactor float64) float64 {
+	return HOME_BONUS_FACTOR * (homeFactor / 10)
+}
+
+// Strength of a team against an opponent, without the home factor and the dynamic contributions
+func rawStrength(attack, midfield, opponentDefense, opponentMidfield float64) float64 {
+	attackStrength := 1.5*attack + midfield
+	opponentDefenseStrength := 1.5*opponentDefense + opponentMidfield
+	return attackStrength / (1 + opponentDefenseStrength/attackStrength)
+}
@@ -0,0 +1,217 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/bit101/go-ansi"
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+type MonteCarloOptions struct {
+	NumSeasons           int
+	EnableTerminalColors bool
+	Seed                 uint64
+	// If set, every season starts from the schedule and results imported from this CSV file
+	FixturesFile string
+	// JSON file with the qualification and relegation zones. If empty, the default zones are used
+	ZonesFile string
+	// Name of the model that draws the score of each match. If empty, independent Poisson draws are used
+	ScoreModel string
+	// Name of the model that decides the score of each match. If empty, the team attributes are used
+	MatchModel string
+	// If set, an odd number of teams is allowed, one of them resting in each round
+	Bye bool
+	// Directory with the league teams. If empty, the dataset of Season (DATASET_CURRENT_SEASON by default) is used
+	TeamsDir string
+	// Season of the dataset with the league teams (see DATASETS_PATH), e.g. 2024
+	Season string
+	// Directory with the datasets. If empty, DATASETS_PATH is looked up in the working directory and next to the executable
+	DatasetsDir string
+}
+
+type MonteCarloTeamResult struct {
+	Name   string
+	Titles int
+	// Number of seasons finished in each zone, indexed by zone name
+	ZoneFinishes     map[string]int
+	PointsSum        float64
+	PointsSquaredSum float64
+	RankSum          float64
+	RankSquaredSum   float64
+}
+
+type MonteCarloReport struct {
+	NumSeasons  int
+	Zones       []Zone
+	TeamResults []*MonteCarloTeamResult
+}
+
+func MonteCarlo(options MonteCarloOptions) {
+	if options.NumSeasons <= 0 {
+		fmt.Fprintf(os.Stderr, "Number of seasons must be positive\n")
+		os.Exit(1)
+	}
+
+	teamsPath, err := teamsPathResolve(options.TeamsDir, options.Season, options.DatasetsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	teams, err := teamsLoad(teamsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	zones, err := zonesLoad(options.ZonesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load zones: %v\n", err)
+		os.Exit(1)
+	}
+
+	scoreModel, err := scoreModelGetWithName(options.ScoreModel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid score model: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = matchModelValidate(options.MatchModel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid match model: %v\n", err)
+		os.Exit(1)
+	}
+
+	newSeasonFunc := newSeason
+	if options.Bye {
+		newSeasonFunc = newSeasonAllowingBye
+	}
+	if options.FixturesFile != "" {
+		importedFixtures, err := fixturesLoad(options.FixturesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to load fixtures: %v\n", err)
+			os.Exit(1)
+		}
+
+		newSeasonFunc = func(teams []*Team, rng *util.Rng) (*Season, error) {
+			return newSeasonFromImportedFixtures(teams, importedFixtures, rng)
+		}
+	}
+
+	fmt.Printf("Seed: [%d]\n", options.Seed)
+	fmt.Printf("Dataset: [%s]\n", datasetDescription(teamsPath, options.TeamsDir, options.Season))
+	report, err := monteCarloRun(teams, newSeasonFunc, zones, options.MatchModel, scoreModel, options.NumSeasons, util.NewRng(options.Seed))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: [%s]\n", err.Error())
+		os.Exit(1)
+	}
+
+	report.print(options.EnableTerminalColors)
+}
+
+func monteCarloRun(teams []*Team, newSeasonFunc func([]*Team, *util.Rng) (*Season, error), zones []Zone, matchModel string, scoreModel ScoreModel, numSeasons int, rng *util.Rng) (MonteCarloReport, error) {
+	resultsMap := make(map[string]*MonteCarloTeamResult)
+	for _, team := range teams {
+		resultsMap[team.Name] = &MonteCarloTeamResult{Name: team.Name, ZoneFinishes: make(map[string]int)}
+	}
+
+	for i := 0; i < numSeasons; i++ {
+		// Each season owns a fresh copy of the teams, so morale and form don't leak between seasons
+		season, err := newSeasonFunc(teams, rng)
+		if err != nil {
+			return MonteCarloReport{}, err
+		}
+		season.zones = zones
+		err = season.setModels(matchModel, scoreModel)
+		if err != nil {
+			return MonteCarloReport{}, err
+		}
+
+		err = season.playAllFixtures()
+		if err != nil {
+			return MonteCarloReport{}, err
+		}
+
+		standings := season.standingsGenerate()
+		for j, teamStatistic := range standings.TeamStatistics {
+			resultsMap[teamStatistic.Name].add(zones, j+1, teamStatistic.Points)
+		}
+	}
+
+	report := MonteCarloReport{NumSeasons: numSeasons, Zones: zones}
+	for _, team := range teams {
+		report.TeamResults = append(report.TeamResults, resultsMap[team.Name])
+	}
+
+	sort.SliceStable(report.TeamResults, func(i, j int) bool {
+		return report.TeamResults[i].RankSum < report.TeamResults[j].RankSum
+	})
+
+	return report, nil
+}
+
+func (r *MonteCarloTeamResult) add(zones []Zone, rank int, points int) {
+	if rank == 1 {
+		r.Titles += 1
+	}
+	zone := zoneForRank(zones, rank)
+	if zone != nil {
+		r.ZoneFinishes[zone.Name] += 1
+	}
+
+	r.PointsSum += float64(points)
+	r.PointsSquaredSum += float64(points * points)
+	r.RankSum += float64(rank)
+	r.RankSquaredSum += float64(rank * rank)
+}
+
+// Returns the mean and the standard deviation of a sample, given its sum and the sum of its squares
+func meanAndStdDev(sum, squaredSum float64, n int) (float64, float64) {
+	mean := sum / float64(n)
+	variance := squaredSum/float64(n) - mean*mean
+	return mean, math.Sqrt(math.Max(variance, 0))
+}
+
+func (r *MonteCarloReport) print(enableTerminalColors bool) {
+	fmt.Printf("Monte Carlo simulation of [%d] seasons\n\n", r.NumSeasons)
+
+	fmt.Printf("%-20s %-8s", "Team", "Title")
+	for _, zone := range r.Zones {
+		fmt.Printf(" %-*s", zoneColumnWidth(zone), zone.Name)
+	}
+	fmt.Printf(" %-14s %-12s\n", "Points", "Rank")
+
+	for _, teamResult := range r.TeamResults {
+		pointsMean, pointsStdDev := meanAndStdDev(teamResult.PointsSum, teamResult.PointsSquaredSum, r.NumSeasons)
+		rankMean, rankStdDev := meanAndStdDev(teamResult.RankSum, teamResult.RankSquaredSum, r.NumSeasons)
+
+		nameFormat := "%-20s"
+		if !enableTerminalColors {
+			fmt.Printf(nameFormat, teamResult.Name)
+		} else {
+			ansi.Printf(getRankPrintColor(r.Zones, int(math.Round(rankMean))), nameFormat, teamResult.Name)
+		}
+
+		fmt.Printf(" %-8s", formatProbability(teamResult.Titles, r.NumSeasons))
+		for _, zone := range r.Zones {
+			fmt.Printf(" %-*s", zoneColumnWidth(zone), formatProbability(teamResult.ZoneFinishes[zone.Name], r.NumSeasons))
+		}
+		fmt.Printf(" %-14s %-12s\n",
+			fmt.Sprintf("%.1f ± %.1f", pointsMean, pointsStdDev),
+			fmt.Sprintf("%.1f ± %.1f", rankMean, rankStdDev))
+	}
+
+	fmt.Println()
+	printZonesLegend(r.Zones, enableTerminalColors)
+}
+
+func zoneColumnWidth(zone Zone) int {
+	return max(len(zone.Name), 7)
+}
+
+func formatProbability(count int, total int) string {
+	return fmt.Sprintf("%.1f%%", 100.0*float64(count)/float64(total))
+}
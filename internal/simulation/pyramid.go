@@ -0,0 +1,239 @@
+package simulation
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Number of teams that go down from each division, and up from the division below it
+	PROMOTION_RELEGATION_SPOTS = 4
+
+	PROMOTION_ZONE_NAME  = "Promotion"
+	RELEGATION_ZONE_NAME = "Relegation"
+)
+
+// A division of the pyramid (e.g. Serie A), with the current members
+type Division struct {
+	Name  string
+	Teams []*Team
+	// Zones used to show the standings of the division
+	Zones []Zone
+}
+
+// Teams that moved between two adjacent divisions at the end of a season
+type DivisionMovement struct {
+	UpperDivision string
+	LowerDivision string
+	Promoted      []string
+	Relegated     []string
+}
+
+// Runs consecutive seasons of all divisions, swapping the bottom teams of each division with the top teams of the one below it
+func simulatePyramid(options Options, topDivisionZones []Zone, rng *util.Rng) error {
+	divisions, err := divisionsLoad(options.DivisionsDirs, topDivisionZones)
+	if err != nil {
+		return err
+	}
+
+	for seasonIdx := 0; seasonIdx < options.NumSeasons; seasonIdx++ {
+		seasons, err := playDivisionsSeason(divisions, rng, options.TieBreakers, options.ScoreModel, options.MatchModel)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n##################################################################\n")
+		fmt.Printf("Season [%d]\n", seasonIdx+1)
+		fmt.Printf("##################################################################\n")
+
+		standings := make([]Standings, 0, len(divisions))
+		for i, division := range divisions {
+			divisionStandings := seasons[i].standingsGenerate()
+			standings = append(standings, divisionStandings)
+
+			fmt.Printf("\n%s\n", division.Name)
+			err = divisionStandings.print(options.EnableTerminalColors)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s champion: [%s]\n", division.Name, divisionStandings.TeamStatistics[0].Name)
+		}
+
+		movements := applyPromotionsAndRelegations(divisions, standings)
+		printDivisionMovements(seasonIdx, movements)
+	}
+
+	return nil
+}
+
+func divisionsLoad(divisionsDirs []string, topDivisionZones []Zone) ([]*Division, error) {
+	divisions := make([]*Division, 0, len(divisionsDirs))
+
+	for i, divisionDir := range divisionsDirs {
+		teams, err := teamsLoad(divisionDir)
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 && len(teams) < PROMOTION_RELEGATION_SPOTS {
+			return nil, fmt.Errorf("division [%s] must have at least %d teams", divisionDir, PROMOTION_RELEGATION_SPOTS)
+		}
+
+		divisions = append(divisions, &Division{
+			Name:  divisionName(i),
+			Teams: teams,
+		})
+	}
+
+	for i, division := range divisions {
+		if i == 0 {
+			division.Zones = topDivisionZones
+		} else {
+			division.Zones = divisionZones(len(division.Teams), i < len(divisions)-1)
+		}
+	}
+
+	return divisions, nil
+}
+
+// Serie A, Serie B, Serie C...
+func divisionName(divisionIdx int) string {
+	return fmt.Sprintf("Serie %c", 'A'+divisionIdx)
+}
+
+func divisionZones(numTeams int, hasRelegation bool) []Zone {
+	zones := []Zone{
+		{PROMOTION_ZONE_NAME, 1, PROMOTION_RELEGATION_SPOTS, "green", "Promoted to the division above"},
+	}
+	if hasRelegation {
+		zones = append(zones, Zone{RELEGATION_ZONE_NAME, numTeams - PROMOTION_RELEGATION_SPOTS + 1, numTeams, "red", "Relegated to the division below"})
+	}
+	return zones
+}
+
+// Plays one season of every division in parallel.
+// Each division gets its own rng, seeded from the main one, so the result doesn't depend on the goroutines scheduling.
+func playDivisionsSeason(divisions []*Division, rng *util.Rng, tieBreakers string, scoreModelName string, matchModelName string) ([]*Season, error) {
+	seasons := make([]*Season, len(divisions))
+	errs := make([]error, len(divisions))
+
+	for i, division := range divisions {
+		season, err := newSeason(division.Teams, util.NewRng(rng.Uint64()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", division.Name, err)
+		}
+
+		season.zones = division.Zones
+		if tieBreakers != "" {
+			season.tieBreakChain, err = tieBreakChainParse(tieBreakers)
+			if err != nil {
+				return nil, err
+			}
+		}
+		scoreModel, err := scoreModelGetWithName(scoreModelName)
+		if err != nil {
+			return nil, err
+		}
+		err = season.setModels(matchModelName, scoreModel)
+		if err != nil {
+			return nil, err
+		}
+
+		seasons[i] = season
+	}
+
+	var wg sync.WaitGroup
+	for i := range seasons {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = seasons[i].playAllFixtures()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", divisions[i].Name, err)
+		}
+	}
+
+	return seasons, nil
+}
+
+// Swaps the bottom teams of each division with the top teams of the division below it
+func applyPromotionsAndRelegations(divisions []*Division, standings []Standings) []DivisionMovement {
+	movements := []DivisionMovement{}
+
+	for i := 0; i+1 < len(divisions); i++ {
+		upperStatistics := standings[i].TeamStatistics
+		lowerStatistics := standings[i+1].TeamStatistics
+
+		movement := DivisionMovement{
+			UpperDivision: divisions[i].Name,
+			LowerDivision: divisions[i+1].Name,
+		}
+		for _, teamStatistic := range upperStatistics[len(upperStatistics)-PROMOTION_RELEGATION_SPOTS:] {
+			movement.Relegated = append(movement.Relegated, teamStatistic.Name)
+		}
+		for _, teamStatistic := range lowerStatistics[:PROMOTION_RELEGATION_SPOTS] {
+			movement.Promoted = append(movement.Promoted, teamStatistic.Name)
+		}
+
+		movements = append(movements, movement)
+	}
+
+	// Only change the memberships after all movements are known, so a team can't move twice in the same season
+	for i, movement := range movements {
+		upperDivision := divisions[i]
+		lowerDivision := divisions[i+1]
+
+		relegatedTeams := removeTeams(upperDivision, movement.Relegated)
+		promotedTeams := removeTeams(lowerDivision, movement.Promoted)
+
+		upperDivision.Teams = append(upperDivision.Teams, promotedTeams...)
+		lowerDivision.Teams = append(lowerDivision.Teams, relegatedTeams...)
+	}
+
+	return movements
+}
+
+// Removes the named teams from the division, returning them
+func removeTeams(division *Division, names []string) []*Team {
+	removed := []*Team{}
+	remaining := []*Team{}
+
+	for _, team := range division.Teams {
+		isRemoved := false
+		for _, name := range names {
+			if team.Name == name {
+				isRemoved = true
+				break
+			}
+		}
+
+		if isRemoved {
+			removed = append(removed, team)
+		} else {
+			remaining = append(remaining, team)
+		}
+	}
+
+	division.Teams = remaining
+	return removed
+}
+
+func printDivisionMovements(seasonIdx int, movements []DivisionMovement) {
+	if len(movements) == 0 {
+		return
+	}
+
+	fmt.Printf("\nSeason [%d] summary:\n", seasonIdx+1)
+	for _, movement := range movements {
+		fmt.Printf("\t- Promoted from %s to %s: %s\n", movement.LowerDivision, movement.UpperDivision, strings.Join(movement.Promoted, ", "))
+		fmt.Printf("\t- Relegated from %s to %s: %s\n", movement.UpperDivision, movement.LowerDivision, strings.Join(movement.Relegated, ", "))
+	}
+}
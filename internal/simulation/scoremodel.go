@@ -0,0 +1,248 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	SCORE_MODEL_POISSON     = "poisson"
+	SCORE_MODEL_DIXON_COLES = "dixon-coles"
+
+	// Dependence between the goals of both teams. Negative values make 0-0 and 1-1 more likely and 1-0 and 0-1 less likely.
+	// Dixon and Coles (1997) estimated values around -0.13 for English football.
+	DIXON_COLES_RHO = -0.13
+	// Scores above this number of goals per team are ignored by the Dixon-Coles model, as their probability is negligible
+	DIXON_COLES_MAX_GOALS = 12
+)
+
+// Draws the final score of a match from the expected goals of each team
+type ScoreModel interface {
+	Name() string
+	sampleScore(homeLambda, awayLambda float64, rng *util.Rng) (int, int)
+}
+
+// Goals of both teams are independent Poisson draws
+type PoissonScoreModel struct{}
+
+// Poisson draws with the Dixon-Coles correction of the low scores
+type DixonColesScoreModel struct {
+	rho float64
+}
+
+var scoreModels = []ScoreModel{
+	PoissonScoreModel{},
+	DixonColesScoreModel{rho: DIXON_COLES_RHO},
+}
+
+// Returns the score model with the received name (the independent Poisson model if empty)
+func scoreModelGetWithName(name string) (ScoreModel, error) {
+	if name == "" {
+		return PoissonScoreModel{}, nil
+	}
+
+	names := []string{}
+	for _, model := range scoreModels {
+		if model.Name() == name {
+			return model, nil
+		}
+		names = append(names, model.Name())
+	}
+	return nil, fmt.Errorf("unknown score model [%s] (available: %s)", name, strings.Join(names, ", "))
+}
+
+func (m PoissonScoreModel) Name() string {
+	return SCORE_MODEL_POISSON
+}
+
+func (m PoissonScoreModel) sampleScore(homeLambda, awayLambda float64, rng *util.Rng) (int, int) {
+	return util.PoissonKnuth(rng, homeLambda), util.PoissonKnuth(rng, awayLambda)
+}
+
+func (m DixonColesScoreModel) Name() string {
+	return SCORE_MODEL_DIXON_COLES
+}
+
+// Samples from the joint distribution tau(x, y) * Poisson(x; homeLambda) * Poisson(y; awayLambda)
+func (m DixonColesScoreModel) sampleScore(homeLambda, awayLambda float64, rng *util.Rng) (int, int) {
+	idx := util.RandomWeightedIndex(rng, m.scoreProbabilities(homeLambda, awayLambda))
+	return idx / (DIXON_COLES_MAX_GOALS + 1), idx % (DIXON_COLES_MAX_GOALS + 1)
+}
+
+// Probability of each score, indexed by home goals * (DIXON_COLES_MAX_GOALS + 1) + away goals.
+// The correction moves probability between the low scores without changing their sum, so only the ignored scores
+// above DIXON_COLES_MAX_GOALS keep the total from being 1.
+func (m DixonColesScoreModel) scoreProbabilities(homeLambda, awayLambda float64) []float64 {
+	// tau must stay non-negative, which limits rho for high expected goals
+	rho := math.Max(m.rho, -1/math.Max(homeLambda, awayLambda))
+	rho = math.Min(rho, 1/math.Max(homeLambda*awayLambda, 1))
+
+	probabilities := make([]float64, 0, (DIXON_COLES_MAX_GOALS+1)*(DIXON_COLES_MAX_GOALS+1))
+	for x := 0; x <= DIXON_COLES_MAX_GOALS; x++ {
+		for y := 0; y <= DIXON_COLES_MAX_GOALS; y++ {
+			probabilities = append(probabilities, dixonColesTau(x, y, homeLambda, awayLambda, rho)*poissonProbability(x, homeLambda)*poissonProbability(y, awayLambda))
+		}
+	}
+	return probabilities
+}
+
+func dixonColesTau(x, y int, homeLambda, awayLambda, rho float64) float64 {
+	switch {
+	case x == 0 && y == 0:
+		return 1 - homeLambda*awayLambda*rho
+	case x == 0 && y == 1:
+		return 1 + homeLambda*rho
+	case x == 1 && y == 0:
+		return 1 + awayLambda*rho
+	case x == 1 && y == 1:
+		return 1 - rho
+	}
+	return 1
+}
+
+func poissonProbability(k int, lambda float64) float64 {
+	if lambda <= 0 {
+		if k == 0 {
+			return 1
+		}
+		return 0
+	}
+	return math.Exp(poissonLogProbability(k, lambda))
+}
+
+// Requires a positive lambda
+func poissonLogProbability(k int, lambda float64) float64 {
+	logProbability := float64(k)*math.Log(lambda) - lambda
+	for i := 2; i <= k; i++ {
+		logProbability -= math.Log(float64(i))
+	}
+	return logProbability
+}
+
+type ScoreModelComparisonOptions struct {
+	NumSeasons int
+	Seed       uint64
+	// Directory with the league teams. If empty, the dataset of Season (DATASET_CURRENT_SEASON by default) is used
+	TeamsDir string
+	// Season of the dataset with the league teams (see DATASETS_PATH), e.g. 2024
+	Season string
+	// Directory with the datasets. If empty, DATASETS_PATH is looked up in the working directory and next to the executable
+	DatasetsDir string
+}
+
+// Frequencies of the results of all matches simulated with a score model
+type ScoreModelStatistics struct {
+	Model    string
+	Matches  int
+	Goals    int
+	HomeWins int
+	Draws    int
+	AwayWins int
+	NilNil   int
+	OneOne   int
+}
+
+// Simulates the same number of league seasons with each score model, starting from the same seed,
+// and compares the resulting goals per match and draw frequencies
+func CompareScoreModels(options ScoreModelComparisonOptions) {
+	if options.NumSeasons <= 0 {
+		fmt.Fprintf(os.Stderr, "Number of seasons must be positive\n")
+		os.Exit(1)
+	}
+
+	teamsPath, err := teamsPathResolve(options.TeamsDir, options.Season, options.DatasetsDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	teams, err := teamsLoad(teamsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load teams: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Seed: [%d]\n", options.Seed)
+	fmt.Printf("Dataset: [%s]\n", datasetDescription(teamsPath, options.TeamsDir, options.Season))
+
+	allStatistics := []ScoreModelStatistics{}
+	for _, model := range scoreModels {
+		statistics, err := scoreModelStatisticsCollect(teams, model, options.NumSeasons, util.NewRng(options.Seed))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: [%s]\n", err.Error())
+			os.Exit(1)
+		}
+		allStatistics = append(allStatistics, statistics)
+	}
+
+	printScoreModelComparison(allStatistics, options.NumSeasons)
+}
+
+func scoreModelStatisticsCollect(teams []*Team, model ScoreModel, numSeasons int, rng *util.Rng) (ScoreModelStatistics, error) {
+	statistics := ScoreModelStatistics{Model: model.Name()}
+
+	for i := 0; i < numSeasons; i++ {
+		season, err := newSeason(teams, rng)
+		if err != nil {
+			return ScoreModelStatistics{}, err
+		}
+		err = season.setModels(MATCH_MODEL_ATTRIBUTES, model)
+		if err != nil {
+			return ScoreModelStatistics{}, err
+		}
+
+		err = season.playAllFixtures()
+		if err != nil {
+			return ScoreModelStatistics{}, err
+		}
+
+		for _, round := range season.schedule.rounds {
+			for _, fixture := range round.fixtures {
+				statistics.add(fixture)
+			}
+		}
+	}
+
+	return statistics, nil
+}
+
+func (s *ScoreModelStatistics) add(f *Fixture) {
+	s.Matches += 1
+	s.Goals += f.homeTeamScore + f.awayTeamScore
+
+	if f.homeTeamScore > f.awayTeamScore {
+		s.HomeWins += 1
+	} else if f.homeTeamScore < f.awayTeamScore {
+		s.AwayWins += 1
+	} else {
+		s.Draws += 1
+		if f.homeTeamScore == 0 {
+			s.NilNil += 1
+		} else if f.homeTeamScore == 1 {
+			s.OneOne += 1
+		}
+	}
+}
+
+func printScoreModelComparison(allStatistics []ScoreModelStatistics, numSeasons int) {
+	fmt.Printf("Score models compared over %d seasons\n", numSeasons)
+
+	format := "%-14s %-10s %-12s %-10s %-10s %-10s %-10s %-10s\n"
+	fmt.Printf(format, "Model", "Matches", "Goals/Match", "HomeWins", "Draws", "AwayWins", "0-0", "1-1")
+
+	percentage := func(count int, total int) string {
+		return fmt.Sprintf("%.1f%%", 100*float64(count)/float64(total))
+	}
+
+	for _, statistics := range allStatistics {
+		fmt.Printf(format, statistics.Model, fmt.Sprint(statistics.Matches),
+			fmt.Sprintf("%.3f", float64(statistics.Goals)/float64(statistics.Matches)),
+			percentage(statistics.HomeWins, statistics.Matches), percentage(statistics.Draws, statistics.Matches),
+			percentage(statistics.AwayWins, statistics.Matches), percentage(statistics.NilNil, statistics.Matches),
+			percentage(statistics.OneOne, statistics.Matches))
+	}
+}
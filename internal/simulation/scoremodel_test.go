@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func TestDixonColesScoreProbabilitiesKeepPoissonTotal(t *testing.T) {
+	model := DixonColesScoreModel{rho: DIXON_COLES_RHO}
+
+	// The last pairs have expected goals high enough for rho to be limited
+	for _, lambdas := range [][2]float64{{1.4, 1.1}, {0.3, 0.2}, {2.5, 0.4}, {9, 8}, {12, 0.1}} {
+		homeLambda, awayLambda := lambdas[0], lambdas[1]
+		probabilities := model.scoreProbabilities(homeLambda, awayLambda)
+
+		total := 0.0
+		poissonTotal := 0.0
+		for idx, probability := range probabilities {
+			if probability < 0 {
+				t.Errorf("lambdas %v: score %d x %d has negative probability %g", lambdas, idx/(DIXON_COLES_MAX_GOALS+1), idx%(DIXON_COLES_MAX_GOALS+1), probability)
+			}
+			total += probability
+			poissonTotal += poissonProbability(idx/(DIXON_COLES_MAX_GOALS+1), homeLambda) * poissonProbability(idx%(DIXON_COLES_MAX_GOALS+1), awayLambda)
+		}
+
+		if math.Abs(total-poissonTotal) > 1e-12 {
+			t.Errorf("lambdas %v: probabilities sum to %g, the independent Poisson ones to %g", lambdas, total, poissonTotal)
+		}
+		if homeLambda < 3 && awayLambda < 3 && math.Abs(total-1) > 1e-4 {
+			t.Errorf("lambdas %v: probabilities sum to %g, expected 1", lambdas, total)
+		}
+	}
+}
+
+func TestDixonColesMakesLowDrawsMoreLikely(t *testing.T) {
+	homeLambda, awayLambda := 1.4, 1.1
+	probabilities := DixonColesScoreModel{rho: DIXON_COLES_RHO}.scoreProbabilities(homeLambda, awayLambda)
+
+	nilNil := probabilities[0]
+	oneOne := probabilities[DIXON_COLES_MAX_GOALS+2]
+	if nilNil <= poissonProbability(0, homeLambda)*poissonProbability(0, awayLambda) {
+		t.Errorf("0-0 has probability %g, not above the independent Poisson one", nilNil)
+	}
+	if oneOne <= poissonProbability(1, homeLambda)*poissonProbability(1, awayLambda) {
+		t.Errorf("1-1 has probability %g, not above the independent Poisson one", oneOne)
+	}
+}
+
+func TestDixonColesSampleScoreFollowsProbabilities(t *testing.T) {
+	homeLambda, awayLambda := 1.4, 1.1
+	model := DixonColesScoreModel{rho: DIXON_COLES_RHO}
+	probabilities := model.scoreProbabilities(homeLambda, awayLambda)
+	rng := util.NewRng(7)
+
+	const numSamples = 20000
+	counts := make([]int, len(probabilities))
+	homeGoals := 0
+	for i := 0; i < numSamples; i++ {
+		homeScore, awayScore := model.sampleScore(homeLambda, awayLambda, rng)
+		counts[homeScore*(DIXON_COLES_MAX_GOALS+1)+awayScore] += 1
+		homeGoals += homeScore
+	}
+
+	for idx, probability := range probabilities {
+		frequency := float64(counts[idx]) / numSamples
+		if math.Abs(frequency-probability) > 0.01 {
+			t.Errorf("score %d x %d: frequency %.4f, probability %.4f", idx/(DIXON_COLES_MAX_GOALS+1), idx%(DIXON_COLES_MAX_GOALS+1), frequency, probability)
+		}
+	}
+	if mean := float64(homeGoals) / numSamples; math.Abs(mean-homeLambda) > 0.05 {
+		t.Errorf("home goals average %.3f, expected %.3f", mean, homeLambda)
+	}
+}
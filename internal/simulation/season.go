@@ -0,0 +1,252 @@
+package simulation
+
+import (
+	"sort"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+// A season owns its teams, its schedule and all the dynamic state built while playing it.
+// Several seasons can exist side by side without interfering with each other.
+type Season struct {
+	teams    map[string]*Team
+	schedule Schedule
+	rng      *util.Rng
+	// Dates of the league rounds and of the competitions played before the league
+	calendar Calendar
+	// Ordered criteria used to rank teams with the same number of points
+	tieBreakChain []TieBreakCriterion
+	// Order drawn once per season, used as the last tie-break criterion
+	drawingOfLots []string
+	// Qualification and relegation zones of the final standings
+	zones []Zone
+	// Draws the score of each match from the expected goals of both teams
+	scoreModel ScoreModel
+	// Decides the score of each match, usually drawing it with the score model
+	matchModel MatchModel
+	// Optional, receives the domain events of the season
+	events *EventEmitter
+	// Optional cup played alongside the league, sharing its teams
+	cup *Cup
+	// Optional Libertadores played alongside the league, sharing its strongest teams
+	libertadores *Libertadores
+	// Optional state championships played before the first league round, sharing the league teams
+	stateChampionships []*StateChampionship
+	// State championships played with the last league round, so they can be printed with it
+	lastPlayedStateChampionships []*StateChampionship
+}
+
+// Creates a new season with the received teams, which must be an even number.
+// Teams are copied, so the season can freely change their dynamic attributes.
+func newSeason(teams []*Team, rng *util.Rng) (*Season, error) {
+	return newSeasonWithScheduleGenerator(teams, generateSchedule, rng)
+}
+
+// Creates a new season with the received teams. If their number is odd, one team rests in each round.
+func newSeasonAllowingBye(teams []*Team, rng *util.Rng) (*Season, error) {
+	return newSeasonWithScheduleGenerator(teams, generateScheduleWithBye, rng)
+}
+
+func newSeasonWithScheduleGenerator(teams []*Team, scheduleGenerator func([]string, *util.Rng) (Schedule, error), rng *util.Rng) (*Season, error) {
+	season, err := newSeasonWithoutSchedule(teams, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := scheduleGenerator(season.teamsGetAllNames(), rng)
+	if err != nil {
+		return nil, err
+	}
+	season.schedule = schedule
+
+	season.schedule.assignDatesIfAvailable(season.calendar)
+
+	return season, nil
+}
+
+func newSeasonWithoutSchedule(teams []*Team, rng *util.Rng) (*Season, error) {
+	season := Season{}
+	season.teams = make(map[string]*Team)
+	season.rng = rng
+
+	for _, team := range teams {
+		seasonTeam := *team
+		seasonTeam.resetDynamicAttributes()
+		season.teams[seasonTeam.Name] = &seasonTeam
+	}
+
+	var err error
+	season.tieBreakChain, err = tieBreakChainParse("")
+	if err != nil {
+		return nil, err
+	}
+	season.drawLots()
+	season.zones = defaultZones
+	season.calendar = defaultCalendar
+	err = season.setModels(MATCH_MODEL_ATTRIBUTES, PoissonScoreModel{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &season, nil
+}
+
+// Sets the models that play the matches of the season. The match model is created anew, so it doesn't share state with other seasons.
+func (s *Season) setModels(matchModelName string, scoreModel ScoreModel) error {
+	matchModel, err := matchModelCreate(matchModelName, scoreModel)
+	if err != nil {
+		return err
+	}
+	s.scoreModel = scoreModel
+	s.matchModel = matchModel
+	return nil
+}
+
+func (s *Season) teamsGetWithName(name string) *Team {
+	return s.teams[name]
+}
+
+// Names are sorted, so iterating over them does not depend on the (random) map iteration order
+func (s *Season) teamsGetAllNames() []string {
+	names := make([]string, 0, len(s.teams))
+	for name := range s.teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Season) playFixture(roundIdx int, f *Fixture) error {
+	context := FixtureContext{RoundIdx: roundIdx}
+	return playFixture(f, s.teamsGetWithName(f.homeTeam), s.teamsGetWithName(f.awayTeam), s.matchModel, s.rng, s.events, context)
+}
+
+// Where a fixture is being played. Competition and Stage are empty for league fixtures.
+type FixtureContext struct {
+	Competition  string
+	Stage        string
+	RoundIdx     int
+	NeutralVenue bool
+}
+
+// Plays a fixture of any competition, emitting the related events
+func playFixture(f *Fixture, homeTeam *Team, awayTeam *Team, matchModel MatchModel, rng *util.Rng, events *EventEmitter, context FixtureContext) error {
+	homeTeamPreviousAttributes := homeTeam.DynamicAttributes
+	awayTeamPreviousAttributes := awayTeam.DynamicAttributes
+
+	strengths, err := f.play(homeTeam, awayTeam, context.NeutralVenue, matchModel, rng)
+	if err != nil {
+		return err
+	}
+
+	err = events.fixturePlayed(context, f, strengths)
+	if err != nil {
+		return err
+	}
+	err = events.dynamicAttributesChanged(homeTeam, homeTeamPreviousAttributes, ATTRIBUTE_CHANGE_REASON_FIXTURE)
+	if err != nil {
+		return err
+	}
+	return events.dynamicAttributesChanged(awayTeam, awayTeamPreviousAttributes, ATTRIBUTE_CHANGE_REASON_FIXTURE)
+}
+
+func (s *Season) playRoundFixtures(roundIdx int) error {
+	for _, fixture := range s.schedule.rounds[roundIdx].fixtures {
+		// Imported seasons may have fixtures that were already played
+		if fixture.played {
+			continue
+		}
+		err := s.playFixture(roundIdx, fixture)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Season) playAllFixtures() error {
+	for !s.schedule.finished {
+		err := s.playNextRoundFixtures()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Season) playNextRoundFixtures() error {
+	if s.schedule.finished {
+		return nil
+	}
+
+	s.lastPlayedStateChampionships = nil
+	for _, championship := range s.stateChampionships {
+		if championship.finished() {
+			continue
+		}
+		err := championship.play()
+		if err != nil {
+			return err
+		}
+		s.lastPlayedStateChampionships = append(s.lastPlayedStateChampionships, championship)
+	}
+
+	err := s.playRoundFixtures(s.schedule.nextRoundIdx)
+	if err != nil {
+		return err
+	}
+
+	s.schedule.advance()
+
+	err = s.events.roundFinished(s)
+	if err != nil {
+		return err
+	}
+
+	// Without a free midweek before the next round, the matchdays scheduled so far are postponed
+	scheduledRoundIdx := s.schedule.currentRoundIdx
+	kickoff, hasFreeMidweek := s.midweekKickoffAfterRound(s.schedule.currentRoundIdx)
+	if !hasFreeMidweek {
+		scheduledRoundIdx = -1
+	}
+
+	if s.cup != nil {
+		err = s.cup.playLegsScheduledAfterRound(scheduledRoundIdx, s.schedule.finished, kickoff)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.libertadores != nil {
+		// The Libertadores is played on Thursdays, so its clubs may also play the Copa do Brasil in the same week
+		return s.libertadores.playMatchdaysScheduledAfterRound(scheduledRoundIdx, s.schedule.finished, addDays(kickoff, 1))
+	}
+
+	return nil
+}
+
+// Applies a GPT-generated random event to a random team, returning the event description
+func (s *Season) generateRandomGptEvent(gptApiKey string) (string, error) {
+	teamsNames := s.teamsGetAllNames()
+	randomPos := util.RandomInt(s.rng, len(teamsNames))
+	randomTeam := s.teamsGetWithName(teamsNames[randomPos])
+	previousAttributes := randomTeam.DynamicAttributes
+
+	eventStr, err := randomTeam.generateGptBasedRandomEvent(gptApiKey, s.rng)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.events.dynamicAttributesChanged(randomTeam, previousAttributes, ATTRIBUTE_CHANGE_REASON_GPT_EVENT)
+	if err != nil {
+		return "", err
+	}
+	err = s.events.gptEventGenerated(s.schedule.currentRoundIdx, randomTeam.Name, eventStr)
+	if err != nil {
+		return "", err
+	}
+
+	return eventStr, nil
+}
@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func loadTestTeams(t *testing.T) []*Team {
+	t.Helper()
+	teams, err := teamsLoad(filepath.Join("..", "..", DATASETS_PATH, DATASET_CURRENT_SEASON, DATASET_LEAGUE))
+	if err != nil {
+		t.Fatalf("unable to load teams: %v", err)
+	}
+	return teams
+}
+
+func playTestSeason(t *testing.T, teams []*Team, seed uint64) *Season {
+	t.Helper()
+	season, err := newSeason(teams, util.NewRng(seed))
+	if err != nil {
+		t.Fatalf("unable to create season: %v", err)
+	}
+	err = season.playAllFixtures()
+	if err != nil {
+		t.Fatalf("unable to play season: %v", err)
+	}
+	return season
+}
+
+func TestSameSeedGivesSameSeason(t *testing.T) {
+	teams := loadTestTeams(t)
+	season1 := playTestSeason(t, teams, 42)
+	season2 := playTestSeason(t, teams, 42)
+
+	if len(season1.schedule.rounds) != len(season2.schedule.rounds) {
+		t.Fatalf("seasons have %d and %d rounds", len(season1.schedule.rounds), len(season2.schedule.rounds))
+	}
+	for i := range season1.schedule.rounds {
+		fixtures1 := season1.schedule.rounds[i].fixtures
+		fixtures2 := season2.schedule.rounds[i].fixtures
+		if len(fixtures1) != len(fixtures2) {
+			t.Fatalf("round %d has %d and %d fixtures", i+1, len(fixtures1), len(fixtures2))
+		}
+		for j := range fixtures1 {
+			f1, f2 := fixtures1[j], fixtures2[j]
+			if f1.homeTeam != f2.homeTeam || f1.awayTeam != f2.awayTeam || f1.homeTeamScore != f2.homeTeamScore || f1.awayTeamScore != f2.awayTeamScore {
+				t.Errorf("round %d: %s %d x %d %s differs from %s %d x %d %s", i+1,
+					f1.homeTeam, f1.homeTeamScore, f1.awayTeamScore, f1.awayTeam, f2.homeTeam, f2.homeTeamScore, f2.awayTeamScore, f2.awayTeam)
+			}
+		}
+	}
+
+	standings1 := season1.standingsGenerate()
+	standings2 := season2.standingsGenerate()
+	for i := range standings1.TeamStatistics {
+		if *standings1.TeamStatistics[i] != *standings2.TeamStatistics[i] {
+			t.Errorf("position %d: %+v differs from %+v", i+1, *standings1.TeamStatistics[i], *standings2.TeamStatistics[i])
+		}
+	}
+}
+
+func TestDifferentSeedsGiveDifferentSeasons(t *testing.T) {
+	teams := loadTestTeams(t)
+	standings1 := playTestSeason(t, teams, 1).standingsGenerate()
+	standings2 := playTestSeason(t, teams, 2).standingsGenerate()
+
+	for i := range standings1.TeamStatistics {
+		if *standings1.TeamStatistics[i] != *standings2.TeamStatistics[i] {
+			return
+		}
+	}
+	t.Errorf("seeds 1 and 2 gave the same standings")
+}
@@ -4,27 +4,274 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/felipeek/brasileirao-simulation/internal/util"
 )
 
-func Simulate(nonInteractive bool, gptApiKey string, enableTerminalColors bool) {
-	err := teamsLoad()
+type Options struct {
+	NonInteractive       bool
+	GptApiKey            string
+	EnableTerminalColors bool
+	Seed                 uint64
+	// If set, the season is resumed from this snapshot file instead of being created from scratch
+	ResumeFile string
+	// If set, the schedule and the results so far are imported from this CSV file
+	FixturesFile string
+	// If set, the final standings and schedule are exported in this format (csv, json or markdown)
+	OutputFormat string
+	// File to which the JSON or Markdown export is written. If empty, it is written to stdout
+	OutputFile string
+	// Directory to which the CSV export is written, one file per table
+	OutputDir string
+	// If set (only ndjson is supported), domain events are written to stdout instead of the human-oriented output
+	Events string
+	// Comma-separated tie-break criteria. If empty, the official CBF order is used
+	TieBreakers string
+	// Name of the model that draws the score of each match. If empty, independent Poisson draws are used
+	ScoreModel string
+	// Name of the model that decides the score of each match. If empty, the team attributes are used
+	MatchModel string
+	// JSON file with the qualification and relegation zones. If empty, the default zones are used
+	ZonesFile string
+	// Team directories of each division of the pyramid, from top to bottom. If empty, only the league teams are used
+	DivisionsDirs []string
+	// Number of consecutive seasons
+	NumSeasons int
+	// If set, the Copa do Brasil is played alongside the league
+	CopaDoBrasil bool
+	// Directory with the foreign clubs of the Copa Libertadores. If set, the Libertadores is played alongside the league
+	LibertadoresDir string
+	// Format files of the state championships played before the league
+	StateChampionshipFiles []string
+	// JSON file with the season dates and breaks. If empty, the default calendar is used
+	CalendarFile string
+	// Directory with the squad files. Teams without one play with their aggregate attributes
+	SquadsDir string
+	// JSON file with the initial Elo rating of each team. Teams without one are rated from their attributes
+	EloRatingsFile string
+	// If set, an odd number of teams is allowed, one of them resting in each round
+	Bye bool
+	// Directory with the league teams. If empty, the dataset of Season (DATASET_CURRENT_SEASON by default) is used
+	TeamsDir string
+	// Season of the dataset with the league teams (see DATASETS_PATH), e.g. 2024
+	Season string
+	// Directory with the datasets. If empty, DATASETS_PATH is looked up in the working directory and next to the executable
+	DatasetsDir string
+	// Directory from which the league teams are loaded, resolved from TeamsDir, Season and DatasetsDir
+	teamsPath string
+}
+
+// The pyramid mode runs several divisions and/or seasons non-interactively, with promotion and relegation
+func (o *Options) isPyramid() bool {
+	return len(o.DivisionsDirs) > 1 || o.NumSeasons > 1
+}
+
+func Simulate(options Options) {
+	if options.OutputFormat != "" && !isValidOutputFormat(options.OutputFormat) {
+		fmt.Fprintf(os.Stderr, "Unknown output format [%s]\n", options.OutputFormat)
+		os.Exit(1)
+	}
+
+	if options.Events != "" && options.Events != EVENTS_FORMAT_NDJSON {
+		fmt.Fprintf(os.Stderr, "Unknown events format [%s]\n", options.Events)
+		os.Exit(1)
+	}
+
+	if options.OutputFormat == OUTPUT_FORMAT_CSV && (options.OutputDir == "" || options.OutputFile != "") {
+		fmt.Fprintf(os.Stderr, "The CSV export writes the standings and the fixtures to separate files, so it requires -output-dir instead of -output-file\n")
+		os.Exit(1)
+	}
+
+	if options.OutputDir != "" && options.OutputFormat != OUTPUT_FORMAT_CSV {
+		fmt.Fprintf(os.Stderr, "-output-dir is only used by the CSV export (use -output-file with the other formats)\n")
+		os.Exit(1)
+	}
+
+	if options.Events != "" && options.exportsToStdout() {
+		fmt.Fprintf(os.Stderr, "The event stream is written to stdout, so the export requires an output file\n")
+		os.Exit(1)
+	}
+
+	tieBreakChain, err := tieBreakChainParse(options.TieBreakers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid tie-break criteria: %v\n", err)
+		os.Exit(1)
+	}
+
+	scoreModel, err := scoreModelGetWithName(options.ScoreModel)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to load teams: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Invalid score model: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = matchModelValidate(options.MatchModel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid match model: %v\n", err)
+		os.Exit(1)
+	}
+
+	zones, err := zonesLoad(options.ZonesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load zones: %v\n", err)
+		os.Exit(1)
+	}
+
+	calendar, err := calendarLoad(options.CalendarFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load calendar: %v\n", err)
+		os.Exit(1)
+	}
+
+	if (options.TeamsDir != "" || options.Season != "" || options.DatasetsDir != "") && options.ResumeFile != "" {
+		fmt.Fprintf(os.Stderr, "The teams of a resumed season can't be changed\n")
+		os.Exit(1)
+	}
+
+	if (options.TeamsDir != "" || options.Season != "") && len(options.DivisionsDirs) > 0 {
+		fmt.Fprintf(os.Stderr, "The teams of each division are chosen with -divisions, which can't be combined with -teams-dir or -season\n")
+		os.Exit(1)
+	}
+
+	// Directories and files inside the datasets are found wherever the league teams are
+	for i := range options.DivisionsDirs {
+		options.DivisionsDirs[i] = datasetPathResolve(options.DivisionsDirs[i], options.DatasetsDir)
+	}
+	for i := range options.StateChampionshipFiles {
+		options.StateChampionshipFiles[i] = datasetPathResolve(options.StateChampionshipFiles[i], options.DatasetsDir)
+	}
+	if options.LibertadoresDir != "" {
+		options.LibertadoresDir = datasetPathResolve(options.LibertadoresDir, options.DatasetsDir)
 	}
 
-	teams := teamsGet()
+	if options.ResumeFile == "" && len(options.DivisionsDirs) == 0 {
+		options.teamsPath, err = teamsPathResolve(options.TeamsDir, options.Season, options.DatasetsDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid teams: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if options.isPyramid() {
+		simulatePyramidMode(options, zones)
+		return
+	}
+
+	if options.CalendarFile != "" && options.ResumeFile != "" {
+		fmt.Fprintf(os.Stderr, "The calendar of a resumed season can't be changed\n")
+		os.Exit(1)
+	}
+
+	if options.SquadsDir != "" && options.ResumeFile != "" {
+		fmt.Fprintf(os.Stderr, "The squads of a resumed season can't be changed\n")
+		os.Exit(1)
+	}
 
-	schedule, err := generateSchedule(teams)
+	if options.EloRatingsFile != "" && options.ResumeFile != "" {
+		fmt.Fprintf(os.Stderr, "The Elo ratings of a resumed season can't be changed\n")
+		os.Exit(1)
+	}
+
+	if (options.CopaDoBrasil || options.LibertadoresDir != "" || len(options.StateChampionshipFiles) > 0) && options.ResumeFile != "" {
+		fmt.Fprintf(os.Stderr, "The Copa do Brasil, the Libertadores and state championships can't be combined with -resume\n")
+		os.Exit(1)
+	}
+
+	season, err := createSeason(options)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to generate fixtures: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Unable to create season: %v\n", err)
+		os.Exit(1)
+	}
+
+	if options.TieBreakers != "" {
+		season.tieBreakChain = tieBreakChain
+	}
+	// Resumed seasons keep their models, unless others are chosen
+	if options.ScoreModel != "" || options.MatchModel != "" {
+		matchModelName := options.MatchModel
+		if matchModelName == "" {
+			matchModelName = season.matchModel.Name()
+		}
+		if options.ScoreModel == "" {
+			scoreModel = season.scoreModel
+		}
+		err = season.setModels(matchModelName, scoreModel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid match model: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	season.zones = zones
+
+	if options.SquadsDir != "" {
+		err = squadsLoad(options.SquadsDir, season.teams)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to load squads: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if options.EloRatingsFile != "" {
+		ratings, err := eloRatingsLoad(options.EloRatingsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to load Elo ratings: %v\n", err)
+			os.Exit(1)
+		}
+		err = ratings.apply(season.teams)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid Elo ratings: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if options.CalendarFile != "" {
+		season.calendar = calendar
+		err = season.schedule.assignDates(calendar)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to assign dates: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if options.printHumanOutput() {
+		season.printDrawingOfLots()
+	}
+
+	if options.Events != "" {
+		season.events = newEventEmitter(os.Stdout)
+	}
+
+	for _, stateChampionshipFile := range options.StateChampionshipFiles {
+		stateChampionship, err := newStateChampionship(stateChampionshipFile, season, options.DatasetsDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to create state championship: %v\n", err)
+			os.Exit(1)
+		}
+		season.stateChampionships = append(season.stateChampionships, stateChampionship)
+	}
+
+	if options.CopaDoBrasil {
+		season.cup, err = newCup(COPA_DO_BRASIL_NAME, season)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to create the %s: %v\n", COPA_DO_BRASIL_NAME, err)
+			os.Exit(1)
+		}
+	}
+
+	if options.LibertadoresDir != "" {
+		season.libertadores, err = newLibertadores(season, options.LibertadoresDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to create the %s: %v\n", COPA_LIBERTADORES_NAME, err)
+			os.Exit(1)
+		}
 	}
 
-	if nonInteractive {
-		err = playAllFixturesNonInteractive(&schedule, enableTerminalColors)
+	if options.Events != "" {
+		err = playAllFixturesWithEvents(season, options)
+	} else if options.NonInteractive {
+		err = playAllFixturesNonInteractive(season, options)
 	} else {
-		err = playAllFixturesIteractive(&schedule, gptApiKey, enableTerminalColors)
+		err = playAllFixturesIteractive(season, options)
 	}
 
 	if err != nil {
@@ -33,59 +280,221 @@ func Simulate(nonInteractive bool, gptApiKey string, enableTerminalColors bool)
 	}
 }
 
-func playAllFixturesNonInteractive(s *Schedule, enableTerminalColors bool) error {
+func simulatePyramidMode(options Options, zones []Zone) {
+	if options.ResumeFile != "" || options.FixturesFile != "" || options.OutputFormat != "" || options.Events != "" || options.CopaDoBrasil || options.LibertadoresDir != "" || len(options.StateChampionshipFiles) > 0 || options.CalendarFile != "" || options.SquadsDir != "" || options.EloRatingsFile != "" {
+		fmt.Fprintf(os.Stderr, "Multiple divisions or seasons can't be combined with -resume, -fixtures, -output-format, -events, -copa-do-brasil, -libertadores, -state-championships, -calendar, -squads or -elo-ratings\n")
+		os.Exit(1)
+	}
+
+	if options.NumSeasons < 1 {
+		options.NumSeasons = 1
+	}
+
+	fmt.Printf("Seed: [%d]\n", options.Seed)
+	if len(options.DivisionsDirs) == 0 {
+		options.DivisionsDirs = []string{options.teamsPath}
+		fmt.Printf("Dataset: [%s]\n", datasetDescription(options.teamsPath, options.TeamsDir, options.Season))
+	}
+	err := simulatePyramid(options, zones, util.NewRng(options.Seed))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: [%s]\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// When events or the export go to stdout, the human-oriented output is omitted
+func (o *Options) printHumanOutput() bool {
+	if o.Events != "" {
+		return false
+	}
+	return !o.NonInteractive || !o.exportsToStdout()
+}
+
+func (o *Options) exportsToStdout() bool {
+	return o.OutputFormat != "" && o.OutputFile == "" && o.OutputDir == ""
+}
+
+func createSeason(options Options) (*Season, error) {
+	if options.ResumeFile != "" {
+		if options.printHumanOutput() {
+			fmt.Printf("Resuming season from [%s]\n", options.ResumeFile)
+		}
+		return seasonResume(options.ResumeFile)
+	}
+
+	if options.printHumanOutput() {
+		fmt.Printf("Seed: [%d]\n", options.Seed)
+		fmt.Printf("Dataset: [%s]\n", datasetDescription(options.teamsPath, options.TeamsDir, options.Season))
+	}
+	rng := util.NewRng(options.Seed)
+
+	teams, err := teamsLoad(options.teamsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.FixturesFile != "" {
+		importedFixtures, err := fixturesLoad(options.FixturesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSeasonFromImportedFixtures(teams, importedFixtures, rng)
+	}
+
+	if options.Bye {
+		return newSeasonAllowingBye(teams, rng)
+	}
+	return newSeason(teams, rng)
+}
+
+func playAllFixturesNonInteractive(s *Season, options Options) error {
 	err := s.playAllFixtures()
 	if err != nil {
 		return err
 	}
-	s.print(enableTerminalColors)
 
-	standings := standingsGenerate(s)
-	err = standings.print(enableTerminalColors)
+	standings := s.standingsGenerate()
+
+	if options.printHumanOutput() {
+		for _, stateChampionship := range s.stateChampionships {
+			stateChampionship.printSummary(options.EnableTerminalColors)
+		}
+
+		s.schedule.print(options.EnableTerminalColors)
+
+		err = standings.print(options.EnableTerminalColors)
+		if err != nil {
+			return err
+		}
+
+		printChampionMessage(standings.TeamStatistics[0].Name)
+
+		if s.cup != nil {
+			s.cup.printBracket()
+		}
+		if s.libertadores != nil {
+			s.libertadores.printSummary(options.EnableTerminalColors)
+		}
+	}
+
+	if options.OutputFormat != "" {
+		return s.export(standings, options.OutputFormat, options.OutputFile, options.OutputDir)
+	}
+
+	return nil
+}
+
+// Plays the season round by round, so the event stream can follow it
+func playAllFixturesWithEvents(s *Season, options Options) error {
+	var seed *uint64
+	if options.ResumeFile == "" {
+		seed = &options.Seed
+	}
+
+	err := s.events.seasonStarted(s, seed)
+	if err != nil {
+		return err
+	}
+
+	for !s.schedule.finished {
+		err = s.playNextRoundFixtures()
+		if err != nil {
+			return err
+		}
+
+		if !s.schedule.finished && options.GptApiKey != "" {
+			_, err = s.generateRandomGptEvent(options.GptApiKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	standings := s.standingsGenerate()
+	err = s.events.championDecided(standings.TeamStatistics[0].Name)
 	if err != nil {
 		return err
 	}
 
-	printChampionMessage(standings.TeamStatistics[0].Name)
+	if options.OutputFormat != "" {
+		return s.export(standings, options.OutputFormat, options.OutputFile, options.OutputDir)
+	}
 
 	return nil
 }
 
-func playAllFixturesIteractive(s *Schedule, gptApiKey string, enableTerminalColors bool) error {
-	fmt.Println("Press [ENTER] to play the next round.")
+func playAllFixturesIteractive(s *Season, options Options) error {
+	enableTerminalColors := options.EnableTerminalColors
+	gptApiKey := options.GptApiKey
+
+	fmt.Println("Press [ENTER] to play the next round, or type [save <file>] to save the season.")
+
+	reader := bufio.NewReader(os.Stdin)
+
+	for !s.schedule.finished {
+		command, _ := reader.ReadString('\n')
+		command = strings.TrimSpace(command)
+
+		if strings.HasPrefix(command, "save") {
+			filePath := strings.TrimSpace(strings.TrimPrefix(command, "save"))
+			if filePath == "" {
+				fmt.Println("Usage: save <file>")
+				continue
+			}
+
+			err := s.save(filePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to save season: %v\n", err)
+			} else {
+				fmt.Printf("Season saved to [%s]. Use -resume %s to continue it later.\n", filePath, filePath)
+			}
+			continue
+		}
 
-	for !s.finished {
-		reader := bufio.NewReader(os.Stdin)
-		reader.ReadString('\n')
 		err := s.playNextRoundFixtures()
 		if err != nil {
 			return err
 		}
-		s.printLastPlayedRound(enableTerminalColors)
+		for _, stateChampionship := range s.lastPlayedStateChampionships {
+			stateChampionship.printSummary(enableTerminalColors)
+		}
+		s.schedule.printLastPlayedRound(enableTerminalColors)
+		if s.cup != nil {
+			s.cup.printLastPlayedLegs()
+		}
+		if s.libertadores != nil {
+			s.libertadores.printLastPlayedMatchdays()
+		}
 
-		standings := standingsGenerate(s)
+		standings := s.standingsGenerate()
 		err = standings.print(enableTerminalColors)
 		if err != nil {
 			return err
 		}
 
-		if s.finished {
+		if s.schedule.finished {
 			printChampionMessage(standings.TeamStatistics[0].Name)
+			if s.cup != nil {
+				s.cup.printBracket()
+			}
+			if s.libertadores != nil {
+				s.libertadores.printSummary(enableTerminalColors)
+			}
+			if options.OutputFormat != "" {
+				return s.export(standings, options.OutputFormat, options.OutputFile, options.OutputDir)
+			}
 			return nil
 		}
 
 		if gptApiKey != "" {
 			reader.ReadString('\n')
 
-			teamsNames := teamsGetAllNames()
-			randomPos := util.RandomInt(len(teamsNames))
-			teamName := teamsNames[randomPos]
-			randomTeam := teamsGetWithName(teamName)
-			eventStr, err := randomTeam.generateGptBasedRandomEvent(gptApiKey)
+			eventStr, err := s.generateRandomGptEvent(gptApiKey)
 			if err != nil {
 				return err
 			}
-			fmt.Printf("Round [%d] Event:\n", s.currentRoundIdx+1)
+			fmt.Printf("Round [%d] Event:\n", s.schedule.currentRoundIdx+1)
 			fmt.Printf("\t- %s\n", eventStr)
 			fmt.Printf("\n\n")
 		}
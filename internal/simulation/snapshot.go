@@ -0,0 +1,350 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+// JSON representation of a season in progress.
+// It holds everything needed to continue the season exactly as it would have continued without the interruption.
+type SeasonSnapshot struct {
+	Rng           []byte
+	Teams         []TeamSnapshot
+	Schedule      ScheduleSnapshot
+	TieBreakChain []string
+	DrawingOfLots []string
+	// Empty in snapshots saved before score models existed, which used independent Poisson draws
+	ScoreModel string
+	// Empty in snapshots saved before match models existed, which used the team attributes
+	MatchModel string
+	// Nil in snapshots saved before the calendar existed, which use the default calendar
+	Calendar *Calendar
+}
+
+type TeamSnapshot struct {
+	Name              string
+	Attack            float64
+	Midfield          float64
+	Defense           float64
+	HomeFactor        float64
+	Morale            float64
+	PhysicalCondition float64
+	// Most recent fixture first, same order as TeamDynamicAttributes.LastFixtures
+	LastFixtures []FixtureReference
+	YellowCards  map[string]int
+	Suspensions  map[string]int
+	// Zero in snapshots saved before Elo ratings existed
+	Elo float64
+	// Nil for teams without a squad
+	Squad *Squad
+}
+
+type FixtureReference struct {
+	Round   int
+	Fixture int
+}
+
+type ScheduleSnapshot struct {
+	CurrentRoundIdx int
+	NextRoundIdx    int
+	Finished        bool
+	Rounds          []RoundSnapshot
+}
+
+type RoundSnapshot struct {
+	Date     time.Time
+	Fixtures []FixtureSnapshot
+}
+
+type FixtureSnapshot struct {
+	HomeTeam      string
+	AwayTeam      string
+	HomeTeamScore int
+	AwayTeamScore int
+	Played        bool
+	Kickoff       time.Time
+	Goals         []GoalSnapshot
+	Cards         []CardSnapshot
+}
+
+type GoalSnapshot struct {
+	Team   string
+	Scorer string
+	Assist string
+	Minute int
+}
+
+type CardSnapshot struct {
+	Team   string
+	Player string
+	Red    bool
+}
+
+func (s *Season) save(filePath string) error {
+	snapshot, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(snapshot, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, raw, 0644)
+}
+
+func seasonResume(filePath string) (*Season, error) {
+	raw, err := util.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot SeasonSnapshot
+	err = json.Unmarshal(raw, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return seasonFromSnapshot(snapshot)
+}
+
+func (s *Season) snapshot() (SeasonSnapshot, error) {
+	if s.cup != nil || s.libertadores != nil || len(s.stateChampionships) > 0 {
+		return SeasonSnapshot{}, fmt.Errorf("saving a season with cups or state championships is not supported")
+	}
+
+	snapshot := SeasonSnapshot{}
+
+	rngState, err := s.rng.MarshalBinary()
+	if err != nil {
+		return SeasonSnapshot{}, err
+	}
+	snapshot.Rng = rngState
+	snapshot.TieBreakChain = tieBreakChainNames(s.tieBreakChain)
+	snapshot.DrawingOfLots = s.drawingOfLots
+	snapshot.ScoreModel = s.scoreModel.Name()
+	snapshot.MatchModel = s.matchModel.Name()
+	calendar := s.calendar
+	snapshot.Calendar = &calendar
+
+	fixtureReferences := make(map[*Fixture]FixtureReference)
+
+	snapshot.Schedule.CurrentRoundIdx = s.schedule.currentRoundIdx
+	snapshot.Schedule.NextRoundIdx = s.schedule.nextRoundIdx
+	snapshot.Schedule.Finished = s.schedule.finished
+	for i, round := range s.schedule.rounds {
+		roundSnapshot := RoundSnapshot{Date: round.date}
+		for j, fixture := range round.fixtures {
+			roundSnapshot.Fixtures = append(roundSnapshot.Fixtures, FixtureSnapshot{
+				HomeTeam:      fixture.homeTeam,
+				AwayTeam:      fixture.awayTeam,
+				HomeTeamScore: fixture.homeTeamScore,
+				AwayTeamScore: fixture.awayTeamScore,
+				Played:        fixture.played,
+				Kickoff:       fixture.kickoff,
+				Goals:         goalsSnapshot(fixture.goals),
+				Cards:         cardsSnapshot(fixture.cards),
+			})
+			fixtureReferences[fixture] = FixtureReference{Round: i, Fixture: j}
+		}
+		snapshot.Schedule.Rounds = append(snapshot.Schedule.Rounds, roundSnapshot)
+	}
+
+	for _, teamName := range s.teamsGetAllNames() {
+		team := s.teamsGetWithName(teamName)
+		teamSnapshot := TeamSnapshot{
+			Name:              team.Name,
+			Attack:            team.Attack,
+			Midfield:          team.Midfield,
+			Defense:           team.Defense,
+			HomeFactor:        team.HomeFactor,
+			Morale:            team.DynamicAttributes.Morale,
+			PhysicalCondition: team.DynamicAttributes.PhysicalCondition,
+			LastFixtures:      []FixtureReference{},
+			YellowCards:       team.DynamicAttributes.YellowCards,
+			Suspensions:       team.DynamicAttributes.Suspensions,
+			Elo:               team.DynamicAttributes.Elo,
+			Squad:             team.Squad,
+		}
+
+		for _, fixture := range team.DynamicAttributes.LastFixtures {
+			reference, ok := fixtureReferences[fixture]
+			if !ok {
+				return SeasonSnapshot{}, fmt.Errorf("fixture of team [%s] is not part of the schedule", team.Name)
+			}
+			teamSnapshot.LastFixtures = append(teamSnapshot.LastFixtures, reference)
+		}
+
+		snapshot.Teams = append(snapshot.Teams, teamSnapshot)
+	}
+
+	return snapshot, nil
+}
+
+func seasonFromSnapshot(snapshot SeasonSnapshot) (*Season, error) {
+	season := Season{}
+	season.teams = make(map[string]*Team)
+	season.zones = defaultZones
+	season.calendar = defaultCalendar
+	if snapshot.Calendar != nil {
+		err := snapshot.Calendar.validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar: %v", err)
+		}
+		season.calendar = *snapshot.Calendar
+	}
+
+	season.rng = util.NewRng(0)
+	err := season.rng.UnmarshalBinary(snapshot.Rng)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rng state: %v", err)
+	}
+
+	season.tieBreakChain, err = tieBreakChainParse(strings.Join(snapshot.TieBreakChain, ","))
+	if err != nil {
+		return nil, err
+	}
+	season.drawingOfLots = snapshot.DrawingOfLots
+	scoreModel, err := scoreModelGetWithName(snapshot.ScoreModel)
+	if err != nil {
+		return nil, err
+	}
+	err = season.setModels(snapshot.MatchModel, scoreModel)
+	if err != nil {
+		return nil, err
+	}
+
+	season.schedule.currentRoundIdx = snapshot.Schedule.CurrentRoundIdx
+	season.schedule.nextRoundIdx = snapshot.Schedule.NextRoundIdx
+	season.schedule.finished = snapshot.Schedule.Finished
+	for _, roundSnapshot := range snapshot.Schedule.Rounds {
+		round := Round{date: roundSnapshot.Date}
+		for _, fixtureSnapshot := range roundSnapshot.Fixtures {
+			fixture := Fixture{
+				homeTeam:      fixtureSnapshot.HomeTeam,
+				awayTeam:      fixtureSnapshot.AwayTeam,
+				homeTeamScore: fixtureSnapshot.HomeTeamScore,
+				awayTeamScore: fixtureSnapshot.AwayTeamScore,
+				played:        fixtureSnapshot.Played,
+				kickoff:       fixtureSnapshot.Kickoff,
+			}
+			for _, goalSnapshot := range fixtureSnapshot.Goals {
+				fixture.goals = append(fixture.goals, Goal{team: goalSnapshot.Team, scorer: goalSnapshot.Scorer, assist: goalSnapshot.Assist, minute: goalSnapshot.Minute})
+			}
+			for _, cardSnapshot := range fixtureSnapshot.Cards {
+				fixture.cards = append(fixture.cards, Card{team: cardSnapshot.Team, player: cardSnapshot.Player, red: cardSnapshot.Red})
+			}
+			round.fixtures = append(round.fixtures, &fixture)
+		}
+		season.schedule.rounds = append(season.schedule.rounds, &round)
+	}
+
+	for _, teamSnapshot := range snapshot.Teams {
+		team := Team{
+			Name:       teamSnapshot.Name,
+			Attack:     teamSnapshot.Attack,
+			Midfield:   teamSnapshot.Midfield,
+			Defense:    teamSnapshot.Defense,
+			HomeFactor: teamSnapshot.HomeFactor,
+		}
+		team.DynamicAttributes.Morale = teamSnapshot.Morale
+		team.DynamicAttributes.PhysicalCondition = teamSnapshot.PhysicalCondition
+		// Snapshots saved before cards existed have no disciplinary records
+		team.DynamicAttributes.YellowCards = make(map[string]int)
+		for player, yellowCards := range teamSnapshot.YellowCards {
+			team.DynamicAttributes.YellowCards[player] = yellowCards
+		}
+		team.DynamicAttributes.Suspensions = make(map[string]int)
+		for player, matches := range teamSnapshot.Suspensions {
+			team.DynamicAttributes.Suspensions[player] = matches
+		}
+		team.DynamicAttributes.Elo = teamSnapshot.Elo
+		if team.DynamicAttributes.Elo == 0 {
+			team.DynamicAttributes.Elo = team.eloRatingFromAttributes()
+		}
+		if teamSnapshot.Squad != nil {
+			if teamSnapshot.Squad.Team != team.Name {
+				return nil, fmt.Errorf("team [%s] has the squad of team [%s]", team.Name, teamSnapshot.Squad.Team)
+			}
+			err := teamSnapshot.Squad.validate()
+			if err != nil {
+				return nil, fmt.Errorf("invalid squad of team [%s]: %v", team.Name, err)
+			}
+			team.Squad = teamSnapshot.Squad
+		}
+		team.DynamicAttributes.LastFixtures = make([]*Fixture, 0, len(teamSnapshot.LastFixtures))
+
+		for _, reference := range teamSnapshot.LastFixtures {
+			if reference.Round < 0 || reference.Round >= len(season.schedule.rounds) ||
+				reference.Fixture < 0 || reference.Fixture >= len(season.schedule.rounds[reference.Round].fixtures) {
+				return nil, fmt.Errorf("team [%s] references an unknown fixture", team.Name)
+			}
+			fixture := season.schedule.rounds[reference.Round].fixtures[reference.Fixture]
+			team.DynamicAttributes.LastFixtures = append(team.DynamicAttributes.LastFixtures, fixture)
+		}
+
+		season.teams[team.Name] = &team
+	}
+
+	err = validateScheduleSnapshot(snapshot.Schedule, season.teams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule: %v", err)
+	}
+
+	return &season, nil
+}
+
+// Checks that the fixtures are played by the snapshot teams and that the round indexes are within the schedule,
+// so a hand-edited or truncated snapshot is rejected instead of failing later
+func validateScheduleSnapshot(schedule ScheduleSnapshot, teams map[string]*Team) error {
+	numRounds := len(schedule.Rounds)
+	if numRounds == 0 {
+		return fmt.Errorf("no rounds")
+	}
+
+	for i, round := range schedule.Rounds {
+		for j, fixture := range round.Fixtures {
+			for _, teamName := range []string{fixture.HomeTeam, fixture.AwayTeam} {
+				if _, ok := teams[teamName]; !ok {
+					return fmt.Errorf("fixture %d of round %d is played by unknown team [%s]", j+1, i+1, teamName)
+				}
+			}
+		}
+	}
+
+	if schedule.CurrentRoundIdx < -1 || schedule.CurrentRoundIdx >= numRounds {
+		return fmt.Errorf("current round index [%d] is out of the %d rounds", schedule.CurrentRoundIdx, numRounds)
+	}
+
+	if schedule.Finished {
+		if schedule.CurrentRoundIdx != numRounds-1 || schedule.NextRoundIdx != -1 {
+			return fmt.Errorf("finished schedule must be at its last round (current round index [%d], next round index [%d])", schedule.CurrentRoundIdx, schedule.NextRoundIdx)
+		}
+	} else if schedule.NextRoundIdx != schedule.CurrentRoundIdx+1 || schedule.NextRoundIdx >= numRounds {
+		return fmt.Errorf("next round index [%d] doesn't follow current round index [%d] within the %d rounds", schedule.NextRoundIdx, schedule.CurrentRoundIdx, numRounds)
+	}
+
+	return nil
+}
+
+func cardsSnapshot(cards []Card) []CardSnapshot {
+	snapshots := []CardSnapshot{}
+	for _, card := range cards {
+		snapshots = append(snapshots, CardSnapshot{Team: card.team, Player: card.player, Red: card.red})
+	}
+	return snapshots
+}
+
+func goalsSnapshot(goals []Goal) []GoalSnapshot {
+	snapshots := []GoalSnapshot{}
+	for _, goal := range goals {
+		snapshots = append(snapshots, GoalSnapshot{Team: goal.team, Scorer: goal.scorer, Assist: goal.assist, Minute: goal.minute})
+	}
+	return snapshots
+}
@@ -0,0 +1,120 @@
+package simulation
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+// A season saved in the middle and resumed plays the remaining rounds exactly as the uninterrupted one,
+// including the squads and a calendar other than the default one
+func TestResumedSeasonContinuesAsUninterrupted(t *testing.T) {
+	newTestSeasonWithSquads := func() *Season {
+		season, err := newSeason(loadTestTeams(t), util.NewRng(5))
+		if err != nil {
+			t.Fatalf("unable to create season: %v", err)
+		}
+		err = squadsLoad(filepath.Join("..", "..", "squads"), season.teams)
+		if err != nil {
+			t.Fatalf("unable to load squads: %v", err)
+		}
+		calendar := defaultCalendar
+		calendar.StartDate = "2024-03-30"
+		calendar.Breaks = calendar.Breaks[:1]
+		season.calendar = calendar
+		err = season.schedule.assignDates(calendar)
+		if err != nil {
+			t.Fatalf("unable to assign dates: %v", err)
+		}
+		return season
+	}
+
+	uninterrupted := newTestSeasonWithSquads()
+	interrupted := newTestSeasonWithSquads()
+	for i := 0; i < 15; i++ {
+		err := interrupted.playNextRoundFixtures()
+		if err != nil {
+			t.Fatalf("unable to play round %d: %v", i+1, err)
+		}
+	}
+
+	filePath := filepath.Join(t.TempDir(), "season.json")
+	err := interrupted.save(filePath)
+	if err != nil {
+		t.Fatalf("unable to save season: %v", err)
+	}
+	resumed, err := seasonResume(filePath)
+	if err != nil {
+		t.Fatalf("unable to resume season: %v", err)
+	}
+
+	if !reflect.DeepEqual(resumed.calendar, interrupted.calendar) {
+		t.Errorf("resumed calendar is %+v, expected %+v", resumed.calendar, interrupted.calendar)
+	}
+	for name, team := range interrupted.teams {
+		if !reflect.DeepEqual(resumed.teams[name].Squad, team.Squad) {
+			t.Errorf("team %s: resumed squad differs from the saved one", name)
+		}
+	}
+
+	err = uninterrupted.playAllFixtures()
+	if err != nil {
+		t.Fatalf("unable to play the uninterrupted season: %v", err)
+	}
+	err = resumed.playAllFixtures()
+	if err != nil {
+		t.Fatalf("unable to play the resumed season: %v", err)
+	}
+
+	for i, round := range uninterrupted.schedule.rounds {
+		resumedRound := resumed.schedule.rounds[i]
+		if !round.date.Equal(resumedRound.date) {
+			t.Errorf("round %d on %s after resuming, expected %s", i+1, formatDate(resumedRound.date), formatDate(round.date))
+		}
+		for j, f1 := range round.fixtures {
+			f2 := resumedRound.fixtures[j]
+			if f1.homeTeam != f2.homeTeam || f1.awayTeam != f2.awayTeam || f1.homeTeamScore != f2.homeTeamScore || f1.awayTeamScore != f2.awayTeamScore ||
+				len(f1.goals) != len(f2.goals) || len(f1.cards) != len(f2.cards) ||
+				(len(f1.goals) > 0 && !reflect.DeepEqual(f1.goals, f2.goals)) || (len(f1.cards) > 0 && !reflect.DeepEqual(f1.cards, f2.cards)) {
+				t.Errorf("round %d: %s %d x %d %s after resuming, expected %s %d x %d %s", i+1,
+					f2.homeTeam, f2.homeTeamScore, f2.awayTeamScore, f2.awayTeam, f1.homeTeam, f1.homeTeamScore, f1.awayTeamScore, f1.awayTeam)
+			}
+		}
+	}
+}
+
+func TestInvalidSnapshotsAreRejected(t *testing.T) {
+	season := playTestSeason(t, loadTestTeams(t), 1)
+	snapshot, err := season.snapshot()
+	if err != nil {
+		t.Fatalf("unable to take snapshot: %v", err)
+	}
+	if _, err := seasonFromSnapshot(snapshot); err != nil {
+		t.Fatalf("valid snapshot was rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(snapshot *SeasonSnapshot)
+	}{
+		{"unknown fixture team", func(snapshot *SeasonSnapshot) { snapshot.Schedule.Rounds[3].Fixtures[0].HomeTeam = "Unknown" }},
+		{"current round out of the schedule", func(snapshot *SeasonSnapshot) { snapshot.Schedule.CurrentRoundIdx = len(snapshot.Schedule.Rounds) }},
+		{"unfinished without a next round", func(snapshot *SeasonSnapshot) { snapshot.Schedule.Finished = false }},
+		{"no rounds", func(snapshot *SeasonSnapshot) { snapshot.Schedule.Rounds = nil }},
+		{"invalid calendar", func(snapshot *SeasonSnapshot) { snapshot.Calendar = &Calendar{StartDate: "13/04/2024"} }},
+		{"squad of another team", func(snapshot *SeasonSnapshot) { snapshot.Teams[0].Squad = &Squad{Team: snapshot.Teams[1].Name} }},
+	}
+
+	for _, test := range tests {
+		changed, err := season.snapshot()
+		if err != nil {
+			t.Fatalf("unable to take snapshot: %v", err)
+		}
+		test.change(&changed)
+		if _, err := seasonFromSnapshot(changed); err == nil {
+			t.Errorf("%s: snapshot was accepted", test.name)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	PLAYER_POSITION_GOALKEEPER = "GK"
+	PLAYER_POSITION_DEFENDER   = "DF"
+	PLAYER_POSITION_MIDFIELDER = "MF"
+	PLAYER_POSITION_FORWARD    = "FW"
+
+	// Players below this fitness are only picked when no fit player is left
+	PLAYER_MIN_FITNESS = 5.0
+	// Share of the rating kept by a player with no fitness at all
+	PLAYER_UNFIT_RATING_FACTOR = 0.8
+	// Share of the rating kept by a player picked out of their position
+	PLAYER_OUT_OF_POSITION_FACTOR = 0.7
+	// Weight of the goalkeeper in the defense of the starting XI, the rest comes from the defenders
+	GOALKEEPER_DEFENSE_WEIGHT = 0.25
+)
+
+// Number of players of each position in the starting XI (4-4-2)
+var lineupFormation = []struct {
+	position string
+	count    int
+}{
+	{PLAYER_POSITION_GOALKEEPER, 1},
+	{PLAYER_POSITION_DEFENDER, 4},
+	{PLAYER_POSITION_MIDFIELDER, 4},
+	{PLAYER_POSITION_FORWARD, 2},
+}
+
+type Player struct {
+	Name     string
+	Position string  // GK, DF, MF or FW
+	Rating   float64 // 0-10
+	Fitness  float64 // 0-10, 0 meaning injured
+}
+
+// Players of a team, loaded from a squad file (see squads/)
+type Squad struct {
+	Team    string
+	Players []*Player
+}
+
+// Starting XI of a team in a fixture, with the attributes derived from it
+type Lineup struct {
+	// Empty for teams without a squad, which play with their aggregate attributes
+	players  []*Player
+	attack   float64
+	midfield float64
+	defense  float64
+}
+
+// Loads all squad files (*.json) of the received directory and attaches them to the teams, reporting the problems of all files together.
+// Teams without a squad file keep playing with their aggregate attributes.
+func squadsLoad(squadsPath string, teams map[string]*Team) error {
+	files, err := os.ReadDir(squadsPath)
+	if err != nil {
+		return err
+	}
+
+	problems := []string{}
+	squadFilePaths := make(map[string]string)
+
+	for _, dirEntry := range files {
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != ".json" {
+			continue
+		}
+
+		filePath := filepath.Join(squadsPath, dirEntry.Name())
+		squad, err := squadFileLoad(filePath)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("[%s]: %v", filePath, err))
+			continue
+		}
+
+		team, ok := teams[squad.Team]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("[%s] field [Team]: unknown team [%s]", filePath, squad.Team))
+			continue
+		}
+		if otherFilePath, ok := squadFilePaths[squad.Team]; ok {
+			problems = append(problems, fmt.Sprintf("[%s] field [Team]: team [%s] also has a squad in [%s]", filePath, squad.Team, otherFilePath))
+			continue
+		}
+		squadFilePaths[squad.Team] = filePath
+		team.Squad = squad
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid squad files in [%s]:\n\t%s", squadsPath, strings.Join(problems, "\n\t"))
+	}
+
+	return nil
+}
+
+func squadFileLoad(filePath string) (*Squad, error) {
+	raw, err := util.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var squad Squad
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&squad)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse: %v", err)
+	}
+
+	err = squad.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &squad, nil
+}
+
+func (s *Squad) validate() error {
+	names := make(map[string]bool)
+	positionCounts := make(map[string]int)
+
+	for _, player := range s.Players {
+		if names[player.Name] {
+			return fmt.Errorf("player [%s] appears more than once", player.Name)
+		}
+		names[player.Name] = true
+
+		if !isValidPlayerPosition(player.Position) {
+			return fmt.Errorf("player [%s] has unknown position [%s]", player.Name, player.Position)
+		}
+		if player.Rating < 0 || player.Rating > 10 {
+			return fmt.Errorf("player [%s] has rating [%.2f] out of the 0-10 range", player.Name, player.Rating)
+		}
+		if player.Fitness < 0 || player.Fitness > 10 {
+			return fmt.Errorf("player [%s] has fitness [%.2f] out of the 0-10 range", player.Name, player.Fitness)
+		}
+		positionCounts[player.Position] += 1
+	}
+
+	for _, slot := range lineupFormation {
+		if positionCounts[slot.position] < slot.count {
+			return fmt.Errorf("at least %d players of position [%s] are needed, found %d", slot.count, slot.position, positionCounts[slot.position])
+		}
+	}
+
+	return nil
+}
+
+func isValidPlayerPosition(position string) bool {
+	for _, slot := range lineupFormation {
+		if slot.position == position {
+			return true
+		}
+	}
+	return false
+}
+
+// Rating of the player considering their fitness
+func (p *Player) effectiveRating() float64 {
+	return p.Rating * (PLAYER_UNFIT_RATING_FACTOR + (1-PLAYER_UNFIT_RATING_FACTOR)*p.Fitness/10)
+}
+
+// Picks the starting XI among the players that are not suspended: the best fit players of each position of the formation,
+// then the best unfit ones. When a position has no players left, it is filled with players of other positions, with a penalty.
+func (s *Squad) pickLineup(suspensions map[string]int) (*Lineup, error) {
+	candidates := []*Player{}
+	for _, player := range s.Players {
+		if suspensions[player.Name] == 0 {
+			candidates = append(candidates, player)
+		}
+	}
+
+	// Fit players first, then by effective rating. Names make the order deterministic.
+	sort.Slice(candidates, func(i, j int) bool {
+		iFit := candidates[i].Fitness >= PLAYER_MIN_FITNESS
+		jFit := candidates[j].Fitness >= PLAYER_MIN_FITNESS
+		if iFit != jFit {
+			return iFit
+		}
+		if candidates[i].effectiveRating() != candidates[j].effectiveRating() {
+			return candidates[i].effectiveRating() > candidates[j].effectiveRating()
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+
+	lineup := Lineup{}
+	picked := make(map[*Player]bool)
+	positionRatings := make(map[string][]float64)
+
+	for _, slot := range lineupFormation {
+		for _, player := range candidates {
+			if len(positionRatings[slot.position]) == slot.count {
+				break
+			}
+			if !picked[player] && player.Position == slot.position {
+				picked[player] = true
+				lineup.players = append(lineup.players, player)
+				positionRatings[slot.position] = append(positionRatings[slot.position], player.effectiveRating())
+			}
+		}
+	}
+
+	for _, slot := range lineupFormation {
+		for _, player := range candidates {
+			if len(positionRatings[slot.position]) == slot.count {
+				break
+			}
+			if !picked[player] {
+				picked[player] = true
+				lineup.players = append(lineup.players, player)
+				positionRatings[slot.position] = append(positionRatings[slot.position], PLAYER_OUT_OF_POSITION_FACTOR*player.effectiveRating())
+			}
+		}
+		if len(positionRatings[slot.position]) < slot.count {
+			return nil, fmt.Errorf("team [%s] does not have enough available players", s.Team)
+		}
+	}
+
+	lineup.attack = util.Average(positionRatings[PLAYER_POSITION_FORWARD])
+	lineup.midfield = util.Average(positionRatings[PLAYER_POSITION_MIDFIELDER])
+	lineup.defense = GOALKEEPER_DEFENSE_WEIGHT*util.Average(positionRatings[PLAYER_POSITION_GOALKEEPER]) +
+		(1-GOALKEEPER_DEFENSE_WEIGHT)*util.Average(positionRatings[PLAYER_POSITION_DEFENDER])
+
+	return &lineup, nil
+}
+
+// Lineup of the team in its next match. Teams without a squad play with their aggregate attributes,
+// weakened by their suspended players.
+func (t *Team) pickLineup() (*Lineup, error) {
+	if t.Squad == nil {
+		penalty := 1 - SUSPENDED_PLAYER_STRENGTH_PENALTY*float64(len(t.DynamicAttributes.Suspensions))
+		return &Lineup{attack: penalty * t.Attack, midfield: penalty * t.Midfield, defense: penalty * t.Defense}, nil
+	}
+	return t.Squad.pickLineup(t.DynamicAttributes.Suspensions)
+}
+
+func (l *Lineup) playerNames() []string {
+	names := make([]string, 0, len(l.players))
+	for _, player := range l.players {
+		names = append(names, player.Name)
+	}
+	return names
+}
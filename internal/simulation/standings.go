@@ -2,8 +2,6 @@ package simulation
 
 import (
 	"fmt"
-	"math/rand"
-	"sort"
 
 	"github.com/bit101/go-ansi"
 	"github.com/felipeek/brasileirao-simulation/internal/util"
@@ -19,51 +17,73 @@ type TeamStatistic struct {
 	GoalsFor     int
 	GoalsAgainst int
 	GoalsDiff    int
+	YellowCards  int
+	RedCards     int
 }
 
 type Standings struct {
 	TeamStatistics         []*TeamStatistic
 	PreviousTeamStatistics []*TeamStatistic
+	// Pairs of adjacent teams that could only be separated by the drawing of lots, as "X ahead of Y"
+	DecidedByLots []string
+	// League top scorers and top assists
+	TopScorers []PlayerTally
+	TopAssists []PlayerTally
+	teams      map[string]*Team
+	zones      []Zone
 }
 
-func standingsGenerate(s *Schedule) Standings {
+func (s *Season) standingsGenerate() Standings {
 	standings := Standings{}
-	standings.TeamStatistics = generateTeamStatisticsUntilRound(s, s.currentRoundIdx)
-	if s.currentRoundIdx > 0 {
-		standings.PreviousTeamStatistics = generateTeamStatisticsUntilRound(s, s.currentRoundIdx-1)
+	standings.teams = s.teams
+	standings.zones = s.zones
+	teamStatistics, deciders := generateTeamStatisticsUntilRound(s, s.schedule.currentRoundIdx)
+	standings.TeamStatistics = teamStatistics
+	standings.DecidedByLots = findPositionsDecidedByLots(teamStatistics, deciders)
+	standings.TopScorers, standings.TopAssists = leaderboardsUntilRound(&s.schedule, s.schedule.currentRoundIdx)
+	if s.schedule.currentRoundIdx > 0 {
+		standings.PreviousTeamStatistics, _ = generateTeamStatisticsUntilRound(s, s.schedule.currentRoundIdx-1)
 	} else {
 		standings.PreviousTeamStatistics = nil
 	}
 	return standings
 }
 
-func generateTeamStatisticsUntilRound(s *Schedule, roundIdx int) []*TeamStatistic {
+// Ranked team statistics, and the name of the tie-break criterion that separated each pair of adjacent teams
+func generateTeamStatisticsUntilRound(s *Season, roundIdx int) ([]*TeamStatistic, []string) {
 	standingsMap := fillStandingsMapUntilRound(s, roundIdx)
 
 	teamStatistics := []*TeamStatistic{}
-	for _, teamStatistic := range standingsMap {
-		teamStatistics = append(teamStatistics, teamStatistic)
+	for _, teamName := range s.teamsGetAllNames() {
+		teamStatistics = append(teamStatistics, standingsMap[teamName])
 	}
 
-	sort.Slice(teamStatistics, func(i, j int) bool {
-		return tieBreak(*teamStatistics[i], *teamStatistics[j], s)
-	})
+	deciders := s.rankTeamStatistics(teamStatistics, roundIdx)
 
-	return teamStatistics
+	return teamStatistics, deciders
 }
 
-func fillStandingsMapUntilRound(s *Schedule, roundIdx int) map[string]*TeamStatistic {
-	teams := teamsGet()
+func findPositionsDecidedByLots(teamStatistics []*TeamStatistic, deciders []string) []string {
+	decidedByLots := []string{}
+	for i, criterion := range deciders {
+		if criterion == TIE_BREAK_DRAWING_OF_LOTS {
+			decidedByLots = append(decidedByLots, fmt.Sprintf("%s ahead of %s", teamStatistics[i].Name, teamStatistics[i+1].Name))
+		}
+	}
+	return decidedByLots
+}
+
+func fillStandingsMapUntilRound(s *Season, roundIdx int) map[string]*TeamStatistic {
 	standingsMap := make(map[string]*TeamStatistic)
 
-	for _, team := range teams {
+	for _, team := range s.teams {
 		teamStatistic := TeamStatistic{}
 		teamStatistic.Name = team.Name
 		standingsMap[team.Name] = &teamStatistic
 	}
 
 	for i := 0; i <= roundIdx; i++ {
-		round := s.rounds[i]
+		round := s.schedule.rounds[i]
 
 		for _, fixture := range round.fixtures {
 			if !fixture.played {
@@ -95,80 +115,38 @@ func fillStandingsMapUntilRound(s *Schedule, roundIdx int) map[string]*TeamStati
 			awayTeamStatistics.GoalsAgainst += fixture.homeTeamScore
 			homeTeamStatistics.GoalsDiff += (fixture.homeTeamScore - fixture.awayTeamScore)
 			awayTeamStatistics.GoalsDiff += (fixture.awayTeamScore - fixture.homeTeamScore)
-		}
-	}
-
-	return standingsMap
-}
-
-func tieBreak(t1, t2 TeamStatistic, s *Schedule) bool {
-	pointsDiff := t1.Points - t2.Points
-	if pointsDiff > 0 {
-		return true
-	} else if pointsDiff < 0 {
-		return false
-	}
-
-	goalsDiff := t1.GoalsDiff - t2.GoalsDiff
-	if goalsDiff > 0 {
-		return true
-	} else if goalsDiff < 0 {
-		return false
-	}
-
-	goalsForDiff := t1.GoalsFor - t2.GoalsFor
-	if goalsForDiff > 0 {
-		return true
-	} else if goalsForDiff < 0 {
-		return false
-	}
 
-	iScore, jScore := summedH2HResults(s, t1.Name, t2.Name)
-	h2hDiff := iScore - jScore
-	if h2hDiff > 0 {
-		return true
-	} else if h2hDiff < 0 {
-		return false
-	}
-
-	return rand.Int()%2 == 0
-}
-
-func summedH2HResults(s *Schedule, team1Name string, team2Name string) (int, int) {
-	team1SummedScore := 0
-	team2SummedScore := 0
-
-	for _, r := range s.rounds {
-		for _, f := range r.fixtures {
-			if f.played && f.homeTeam == team1Name && f.awayTeam == team2Name {
-				team1SummedScore += f.homeTeamScore
-				team2SummedScore += f.awayTeamScore
-			} else if f.played && f.homeTeam == team2Name && f.awayTeam == team1Name {
-				team2SummedScore += f.homeTeamScore
-				team1SummedScore += f.awayTeamScore
-			}
+			homeYellowCards, homeRedCards := fixture.cardsOf(fixture.homeTeam)
+			awayYellowCards, awayRedCards := fixture.cardsOf(fixture.awayTeam)
+			homeTeamStatistics.YellowCards += homeYellowCards
+			homeTeamStatistics.RedCards += homeRedCards
+			awayTeamStatistics.YellowCards += awayYellowCards
+			awayTeamStatistics.RedCards += awayRedCards
 		}
 	}
 
-	return team1SummedScore, team2SummedScore
+	return standingsMap
 }
 
 func (s *Standings) print(enableTerminalColors bool) error {
-	headerFormat := "%-6s %-20s %-8s %-6s %-6s %-6s %-6s %-9s %-12s %-9s %-12s %-6s %-6s %-6s\n"
+	headerFormat := "%-6s %-20s %-8s %-6s %-6s %-6s %-6s %-9s %-12s %-9s %-6s %-6s %-12s %-6s %-6s %-8s %-6s %-6s %-*s\n"
+	zoneWidth := zonesNameWidth(s.zones)
 	fmt.Printf(headerFormat, "Rank", "Team", "Matches", "Points", "Won", "Drawn", "Lost",
-		"GoalsFor", "GoalsAgainst", "GoalsDiff", "RecentForm", "Change", "Morale", "PhysCond")
+		"GoalsFor", "GoalsAgainst", "GoalsDiff", "Yellow", "Red", "RecentForm", "Change", "Morale", "PhysCond", "Elo", "Power", zoneWidth, "Zone")
+
+	powerRanks := powerRanking(s.teams)
 
 	for i, teamStatistics := range s.TeamStatistics {
-		team := teamsGetWithName(teamStatistics.Name)
+		team := s.teams[teamStatistics.Name]
 		teamRecentFiveGoalDiffs := getTeamRecentFiveGoalDiffs(teamStatistics.Name, team.DynamicAttributes.LastFixtures)
 		teamPositionChange, err := getTeamPositionChange(teamStatistics.Name, s.TeamStatistics, s.PreviousTeamStatistics)
 		if err != nil {
 			return err
 		}
 
-		printStandingsRank(enableTerminalColors, i+1)
+		printStandingsRank(enableTerminalColors, s.zones, i+1)
 		fmt.Printf(" ")
-		printStandingsTeamName(enableTerminalColors, i+1, teamStatistics.Name)
+		printStandingsTeamName(enableTerminalColors, s.zones, i+1, teamStatistics.Name)
 		fmt.Printf(" ")
 		printStandingsMatches(enableTerminalColors, teamStatistics.Matches)
 		fmt.Printf(" ")
@@ -186,6 +164,10 @@ func (s *Standings) print(enableTerminalColors bool) error {
 		fmt.Printf(" ")
 		printStandingsGoalsDiff(enableTerminalColors, teamStatistics.GoalsDiff)
 		fmt.Printf(" ")
+		printStandingsCards(enableTerminalColors, teamStatistics.YellowCards, ansi.BoldYellow)
+		fmt.Printf(" ")
+		printStandingsCards(enableTerminalColors, teamStatistics.RedCards, ansi.BoldRed)
+		fmt.Printf(" ")
 		printStandingsRecentForm(enableTerminalColors, teamRecentFiveGoalDiffs)
 		fmt.Printf(" ")
 		printStandingsChanges(enableTerminalColors, teamPositionChange)
@@ -193,27 +175,64 @@ func (s *Standings) print(enableTerminalColors bool) error {
 		printStandingsMorale(enableTerminalColors, team.DynamicAttributes.Morale)
 		fmt.Printf(" ")
 		printStandingsPhysicalCondition(enableTerminalColors, team.DynamicAttributes.PhysicalCondition)
+		fmt.Printf("   ")
+		printStandingsElo(enableTerminalColors, team.DynamicAttributes.Elo)
+		fmt.Printf(" ")
+		printStandingsPowerRank(enableTerminalColors, powerRanks[teamStatistics.Name], i+1)
+		fmt.Printf(" ")
+		printStandingsZone(enableTerminalColors, s.zones, i+1, zoneWidth)
 		fmt.Println()
 	}
 
+	printZonesLegend(s.zones, enableTerminalColors)
+
+	for _, decidedByLots := range s.DecidedByLots {
+		fmt.Printf("* Decided by drawing of lots: %s\n", decidedByLots)
+	}
+
+	printLeaderboards(s.TopScorers, s.TopAssists)
+
 	return nil
 }
 
-func printStandingsRank(enableTerminalColors bool, rank int) {
+func groupName(groupIdx int) string {
+	return fmt.Sprintf("Group %c", 'A'+groupIdx)
+}
+
+// Prints a compact standings table, for competitions that have many groups
+func printGroupStandings(teamStatistics []*TeamStatistic, teams map[string]*Team, zones []Zone, enableTerminalColors bool) {
+	format := "\t%-6s %-24s %-12s %-8s %-6s %-6s %-6s %-6s %-9s %-12s %-9s\n"
+	fmt.Printf(format, "Rank", "Team", "Country", "Matches", "Points", "Won", "Drawn", "Lost", "GoalsFor", "GoalsAgainst", "GoalsDiff")
+
+	for i, teamStatistic := range teamStatistics {
+		line := fmt.Sprintf(format, fmt.Sprint(i+1), teamStatistic.Name, teams[teamStatistic.Name].country(),
+			fmt.Sprint(teamStatistic.Matches), fmt.Sprint(teamStatistic.Points), fmt.Sprint(teamStatistic.Won),
+			fmt.Sprint(teamStatistic.Drawn), fmt.Sprint(teamStatistic.Lost), fmt.Sprint(teamStatistic.GoalsFor),
+			fmt.Sprint(teamStatistic.GoalsAgainst), fmt.Sprint(teamStatistic.GoalsDiff))
+
+		if !enableTerminalColors {
+			fmt.Print(line)
+		} else {
+			ansi.Printf(getRankPrintColor(zones, i+1), "%s", line)
+		}
+	}
+}
+
+func printStandingsRank(enableTerminalColors bool, zones []Zone, rank int) {
 	format := "%-6d"
 	if !enableTerminalColors {
 		fmt.Printf(format, rank)
 	} else {
-		ansi.Printf(getRankPrintColor(rank), format, rank)
+		ansi.Printf(getRankPrintColor(zones, rank), format, rank)
 	}
 }
 
-func printStandingsTeamName(enableTerminalColors bool, rank int, teamName string) {
+func printStandingsTeamName(enableTerminalColors bool, zones []Zone, rank int, teamName string) {
 	format := "%-20s"
 	if !enableTerminalColors {
 		fmt.Printf(format, teamName)
 	} else {
-		ansi.Printf(getRankPrintColor(rank), format, teamName)
+		ansi.Printf(getRankPrintColor(zones, rank), format, teamName)
 	}
 }
 
@@ -289,6 +308,15 @@ func printStandingsGoalsDiff(enableTerminalColors bool, goalsDiff int) {
 	}
 }
 
+func printStandingsCards(enableTerminalColors bool, cards int, color ansi.AnsiColor) {
+	format := "%-6d"
+	if !enableTerminalColors {
+		fmt.Printf(format, cards)
+	} else {
+		ansi.Printf(color, format, cards)
+	}
+}
+
 func printStandingsRecentForm(enableTerminalColors bool, lastFiveGoalDiffs [5]*int) {
 	matchChar := "● "
 	noMatchChar := "─ "
@@ -363,6 +391,17 @@ func printStandingsPhysicalCondition(enableTerminalColors bool, physicalConditio
 		ansi.Printf(getAttributeValuePrintColor(physicalCondition), format, physicalCondition)
 	}
 }
+
+func printStandingsZone(enableTerminalColors bool, zones []Zone, rank int, width int) {
+	format := "%-*s"
+	zoneName := zoneNameForRank(zones, rank)
+	if !enableTerminalColors {
+		fmt.Printf(format, width, zoneName)
+	} else {
+		ansi.Printf(getRankPrintColor(zones, rank), format, width, zoneName)
+	}
+}
+
 func getTeamRecentFiveGoalDiffs(teamName string, recentMatches []*Fixture) [5]*int {
 	var result [5]*int
 
@@ -414,17 +453,3 @@ func getTeamPositionChange(teamName string, currentStatistics []*TeamStatistic,
 
 	return previousPosition - currentPosition, nil
 }
-
-func getRankPrintColor(rank int) ansi.AnsiColor {
-	if rank <= 4 {
-		return ansi.BoldCyan
-	} else if rank <= 6 {
-		return ansi.BoldBlue
-	} else if rank <= 12 {
-		return ansi.BoldYellow
-	} else if rank <= 16 {
-		return ansi.BoldWhite
-	} else {
-		return ansi.BoldRed
-	}
-}
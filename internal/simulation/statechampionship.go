@@ -0,0 +1,338 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+const (
+	// Each team plays the teams of its own group
+	GROUP_FORMAT_ROUND_ROBIN = "round-robin"
+	// Each team plays only the teams of the other groups, as in the Paulistao
+	GROUP_FORMAT_CROSS_GROUP = "cross-group"
+
+	// The first knockout stage pairs the best and the worst qualified teams, by group stage campaign
+	KNOCKOUT_PAIRING_SEEDED = "seeded"
+	// The first knockout stage pairs the first and the second of each group
+	KNOCKOUT_PAIRING_SAME_GROUP = "same-group"
+)
+
+// Description of a state championship, loaded from a JSON file (see datasets/2024/state-championships/)
+type StateChampionshipFormat struct {
+	Name string
+	// Directory with the clubs that don't play the league. Clubs of the league are shared with it.
+	// Paths inside the datasets (e.g. datasets/2024/paulistao/) are resolved like the datasets themselves.
+	TeamsDir string
+	Groups   [][]string
+	// GROUP_FORMAT_ROUND_ROBIN or GROUP_FORMAT_CROSS_GROUP
+	GroupFormat string
+	// Number of times each pair of teams meets in the group stage (1 or 2)
+	GroupLegs         int
+	QualifiedPerGroup int
+	// KNOCKOUT_PAIRING_SEEDED or KNOCKOUT_PAIRING_SAME_GROUP. Later stages are always seeded.
+	KnockoutPairing string
+	// Number of legs of the knockout ties before the final (1 or 2)
+	KnockoutLegs int
+	// Number of legs of the final (1 or 2)
+	FinalLegs int
+}
+
+// A state championship, played before the league. League clubs are shared with it,
+// so their state championship campaign carries over into their dynamic attributes.
+type StateChampionship struct {
+	format     StateChampionshipFormat
+	teams      map[string]*Team
+	rng        *util.Rng
+	matchModel MatchModel
+	events     *EventEmitter
+	// The group stage is played as a single season, so all clubs are ranked by the league standings code
+	groupStage *Season
+	// Position of each club in the group stage standings, used to seed the knockout stage
+	campaignRanks map[string]int
+	stages        []*KnockoutStage
+	champion      string
+	// Kickoff of the first matchday. The championship is played twice a week.
+	startKickoff time.Time
+}
+
+func stateChampionshipFormatLoad(formatPath string) (StateChampionshipFormat, error) {
+	raw, err := util.ReadFile(formatPath)
+	if err != nil {
+		return StateChampionshipFormat{}, err
+	}
+
+	var format StateChampionshipFormat
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&format)
+	if err != nil {
+		return StateChampionshipFormat{}, fmt.Errorf("unable to parse state championship [%s]: %v", formatPath, err)
+	}
+
+	err = format.validate()
+	if err != nil {
+		return StateChampionshipFormat{}, fmt.Errorf("invalid state championship [%s]: %v", formatPath, err)
+	}
+
+	return format, nil
+}
+
+func (f *StateChampionshipFormat) validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("the championship has no name")
+	}
+	if len(f.Groups) == 0 {
+		return fmt.Errorf("the championship has no groups")
+	}
+	for i, group := range f.Groups {
+		if len(group) != len(f.Groups[0]) {
+			return fmt.Errorf("group %d has %d teams, but group 1 has %d", i+1, len(group), len(f.Groups[0]))
+		}
+	}
+
+	if f.GroupFormat != GROUP_FORMAT_ROUND_ROBIN && f.GroupFormat != GROUP_FORMAT_CROSS_GROUP {
+		return fmt.Errorf("unknown group format [%s] (available: %s, %s)", f.GroupFormat, GROUP_FORMAT_ROUND_ROBIN, GROUP_FORMAT_CROSS_GROUP)
+	}
+	if f.KnockoutPairing != KNOCKOUT_PAIRING_SEEDED && f.KnockoutPairing != KNOCKOUT_PAIRING_SAME_GROUP {
+		return fmt.Errorf("unknown knockout pairing [%s] (available: %s, %s)", f.KnockoutPairing, KNOCKOUT_PAIRING_SEEDED, KNOCKOUT_PAIRING_SAME_GROUP)
+	}
+	if f.KnockoutPairing == KNOCKOUT_PAIRING_SAME_GROUP && f.QualifiedPerGroup != 2 {
+		return fmt.Errorf("the %s pairing needs exactly 2 qualified teams per group", KNOCKOUT_PAIRING_SAME_GROUP)
+	}
+
+	for _, legs := range []int{f.GroupLegs, f.KnockoutLegs, f.FinalLegs} {
+		if legs != 1 && legs != 2 {
+			return fmt.Errorf("the number of legs must be 1 or 2")
+		}
+	}
+
+	numQualified := f.QualifiedPerGroup * len(f.Groups)
+	if f.QualifiedPerGroup < 1 || f.QualifiedPerGroup > len(f.Groups[0]) {
+		return fmt.Errorf("invalid number of qualified teams per group [%d]", f.QualifiedPerGroup)
+	}
+	if numQualified < 2 || numQualified&(numQualified-1) != 0 {
+		return fmt.Errorf("the knockout stage needs a power of two teams, but %d qualify", numQualified)
+	}
+
+	return nil
+}
+
+// Creates a state championship from its format file. Its league clubs are taken from the season.
+func newStateChampionship(formatPath string, season *Season, datasetsDir string) (*StateChampionship, error) {
+	format, err := stateChampionshipFormatLoad(formatPath)
+	if err != nil {
+		return nil, err
+	}
+
+	championship := StateChampionship{
+		format:     format,
+		teams:      make(map[string]*Team),
+		rng:        season.rng,
+		matchModel: season.matchModel,
+		events:     season.events,
+	}
+
+	otherTeams := make(map[string]*Team)
+	if format.TeamsDir != "" {
+		teams, err := teamsLoad(datasetPathResolve(format.TeamsDir, datasetsDir))
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			otherTeams[team.Name] = team
+		}
+	}
+
+	for _, group := range format.Groups {
+		for _, name := range group {
+			if _, ok := championship.teams[name]; ok {
+				return nil, fmt.Errorf("club [%s] appears more than once in the %s", name, format.Name)
+			}
+
+			if team := season.teamsGetWithName(name); team != nil {
+				championship.teams[name] = team
+			} else if team, ok := otherTeams[name]; ok {
+				championship.teams[name] = team
+			} else {
+				return nil, fmt.Errorf("club [%s] of the %s is neither in the league nor in [%s]", name, format.Name, format.TeamsDir)
+			}
+		}
+	}
+
+	championship.groupStage = &Season{
+		teams:         championship.teams,
+		rng:           championship.rng,
+		tieBreakChain: season.tieBreakChain,
+		zones:         []Zone{{"Knockout", 1, format.QualifiedPerGroup, "cyan", "Qualified to the knockout stage"}},
+	}
+
+	if format.GroupFormat == GROUP_FORMAT_CROSS_GROUP {
+		championship.groupStage.schedule, err = generateCrossGroupSchedule(format.Groups, format.GroupLegs)
+	} else {
+		championship.groupStage.schedule, err = generateWithinGroupsSchedule(format.Groups, format.GroupLegs, championship.rng)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate the %s schedule: %v", format.Name, err)
+	}
+	championship.groupStage.drawLots()
+
+	startDate, err := calendarParseDate(season.calendar.StateChampionshipsStartDate)
+	if err != nil {
+		return nil, err
+	}
+	championship.startKickoff = midweekKickoff(startDate)
+
+	return &championship, nil
+}
+
+func (c *StateChampionship) finished() bool {
+	return c.champion != ""
+}
+
+// Plays the whole championship, from the group stage to the final
+func (c *StateChampionship) play() error {
+	kickoff := c.startKickoff
+
+	for !c.groupStage.schedule.finished {
+		roundIdx := c.groupStage.schedule.nextRoundIdx
+		context := FixtureContext{Competition: c.format.Name, Stage: "Group stage", RoundIdx: roundIdx}
+
+		round := c.groupStage.schedule.rounds[roundIdx]
+		round.date = kickoff.Truncate(24 * time.Hour)
+		for _, fixture := range round.fixtures {
+			fixture.kickoff = kickoff
+			err := playFixture(fixture, c.teams[fixture.homeTeam], c.teams[fixture.awayTeam], c.matchModel, c.rng, c.events, context)
+			if err != nil {
+				return err
+			}
+		}
+		c.groupStage.schedule.advance()
+		kickoff = nextMatchdayKickoff(kickoff)
+	}
+
+	c.addKnockoutStage(c.firstKnockoutStagePairs())
+
+	for !c.finished() {
+		stage := c.stages[len(c.stages)-1]
+		for legIdx := 0; legIdx < stage.numLegs; legIdx++ {
+			err := stage.playLeg(legIdx, kickoff, c.teams, c.matchModel, c.rng, c.events, c.format.Name)
+			if err != nil {
+				return err
+			}
+			kickoff = nextMatchdayKickoff(kickoff)
+		}
+		stage.resolve(c.teams, c.rng)
+
+		winners := stage.winners()
+		if len(winners) == 1 {
+			c.champion = winners[0]
+		} else {
+			c.addKnockoutStage(c.seededPairs(winners))
+		}
+	}
+
+	return nil
+}
+
+// Standings of each group, in the order of the group stage standings
+func (c *StateChampionship) groupsStandings() [][]*TeamStatistic {
+	groupIdxs := make(map[string]int)
+	for i, group := range c.format.Groups {
+		for _, name := range group {
+			groupIdxs[name] = i
+		}
+	}
+
+	groupsStandings := make([][]*TeamStatistic, len(c.format.Groups))
+	for _, teamStatistic := range c.groupStage.standingsGenerate().TeamStatistics {
+		groupIdx := groupIdxs[teamStatistic.Name]
+		groupsStandings[groupIdx] = append(groupsStandings[groupIdx], teamStatistic)
+	}
+	return groupsStandings
+}
+
+func (c *StateChampionship) firstKnockoutStagePairs() [][2]string {
+	c.campaignRanks = make(map[string]int)
+	for i, teamStatistic := range c.groupStage.standingsGenerate().TeamStatistics {
+		c.campaignRanks[teamStatistic.Name] = i + 1
+	}
+
+	qualified := []string{}
+	pairs := [][2]string{}
+	for _, groupStandings := range c.groupsStandings() {
+		for _, teamStatistic := range groupStandings[:c.format.QualifiedPerGroup] {
+			qualified = append(qualified, teamStatistic.Name)
+		}
+		if c.format.KnockoutPairing == KNOCKOUT_PAIRING_SAME_GROUP {
+			pairs = append(pairs, [2]string{groupStandings[0].Name, groupStandings[1].Name})
+		}
+	}
+
+	if c.format.KnockoutPairing == KNOCKOUT_PAIRING_SAME_GROUP {
+		return pairs
+	}
+	return c.seededPairs(qualified)
+}
+
+// Pairs the best with the worst of the received teams, by group stage campaign
+func (c *StateChampionship) seededPairs(teamNames []string) [][2]string {
+	sorted := append([]string{}, teamNames...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return c.campaignRanks[sorted[i]] < c.campaignRanks[sorted[j]]
+	})
+
+	pairs := [][2]string{}
+	for i := 0; i < len(sorted)/2; i++ {
+		pairs = append(pairs, [2]string{sorted[i], sorted[len(sorted)-1-i]})
+	}
+	return pairs
+}
+
+// The team with the best campaign plays the single match, or the second leg, at home
+func (c *StateChampionship) addKnockoutStage(pairs [][2]string) {
+	numLegs := c.format.KnockoutLegs
+	if len(pairs) == 1 {
+		numLegs = c.format.FinalLegs
+	}
+
+	stage := KnockoutStage{name: knockoutStageName(2 * len(pairs)), numLegs: numLegs}
+	for _, pair := range pairs {
+		best, worst := pair[0], pair[1]
+		if c.campaignRanks[worst] < c.campaignRanks[best] {
+			best, worst = worst, best
+		}
+
+		if numLegs == 1 {
+			stage.ties = append(stage.ties, newKnockoutTie(best, worst, numLegs))
+		} else {
+			stage.ties = append(stage.ties, newKnockoutTie(worst, best, numLegs))
+		}
+	}
+
+	c.stages = append(c.stages, &stage)
+}
+
+// Prints the group standings and the knockout stages
+func (c *StateChampionship) printSummary(enableTerminalColors bool) {
+	fmt.Println()
+	fmt.Printf("%s\n", c.format.Name)
+
+	for i, groupStandings := range c.groupsStandings() {
+		fmt.Printf("%s\n", groupName(i))
+		printGroupStandings(groupStandings, c.teams, c.groupStage.zones, enableTerminalColors)
+	}
+	printZonesLegend(c.groupStage.zones, enableTerminalColors)
+
+	for _, stage := range c.stages {
+		stage.print()
+	}
+
+	if c.finished() {
+		fmt.Printf("%s champion: [%s]\n", c.format.Name, c.champion)
+	}
+}
@@ -6,25 +6,39 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"slices"
+	"sort"
+	"strings"
 
 	"github.com/felipeek/brasileirao-simulation/internal/gpt"
 	"github.com/felipeek/brasileirao-simulation/internal/util"
 )
 
 type Team struct {
-	Name              string
-	Attack            float64 // 0-10
-	Midfield          float64 // 0-10
-	Defense           float64 // 0-10
-	HomeFactor        float64 // 0-10
+	Name       string
+	Country    string  // Empty for Brazilian clubs
+	Attack     float64 // 0-10
+	Midfield   float64 // 0-10
+	Defense    float64 // 0-10
+	HomeFactor float64 // 0-10
+	// Optional, loaded from a squad file. When set, Attack, Midfield and Defense are derived from the starting XI.
+	Squad             *Squad `json:"-"`
 	DynamicAttributes TeamDynamicAttributes
+	// File the team was loaded from
+	filePath string
 }
 
 type TeamDynamicAttributes struct {
 	LastFixtures      []*Fixture
 	Morale            float64 // 0-10
 	PhysicalCondition float64 // 0-10
+	// Yellow cards of each player since their last suspension
+	YellowCards map[string]int
+	// Matches each suspended player still has to miss
+	Suspensions map[string]int
+	// Elo rating, updated after every played fixture
+	Elo float64
 }
 
 const (
@@ -38,63 +52,157 @@ type AttributeType struct {
 }
 
 const (
-	TEAMS_PATH = "teams/"
+	// Country of the teams whose files don't specify one
+	DEFAULT_TEAM_COUNTRY = "Brazil"
 
 	// The bigger the value, the bigger the potential morale update values
 	MORALE_UPDATE_STDDEV = 0.2
 	// The bigger the value, the bigger the potential physical condition update values
 	PHYSICAL_CONDITION_UPDATE_STDDEV = 0.3
+	// Physical condition lost in each match
+	PHYSICAL_CONDITION_MATCH_DRAIN = 1.7
+	// Physical condition of a fully rested team. Rest days recover the condition towards it.
+	PHYSICAL_CONDITION_RESTED = 7.0
+	// Rest days needed to recover ~63% of the distance to the rested condition.
+	// With these values, teams playing once a week stay around 5, and teams playing twice a week around 3.5.
+	PHYSICAL_CONDITION_RECOVERY_DAYS = 7.0
+	// Rest days assumed when the previous match of the team or its date is unknown
+	PHYSICAL_CONDITION_DEFAULT_REST_DAYS = 7
 )
 
-var teams map[string]*Team = make(map[string]*Team)
+// Contents of a team file
+type teamFile struct {
+	Name       string
+	Country    string `json:",omitempty"`
+	Attack     float64
+	Midfield   float64
+	Defense    float64
+	HomeFactor float64
+}
+
+// Fields of a team file, in the order they are checked. Only Country is optional.
+var teamFileFields = []string{"Name", "Country", "Attack", "Midfield", "Defense", "HomeFactor"}
 
-func teamsLoad() error {
-	files, err := os.ReadDir(TEAMS_PATH)
+// Loads all team files (*.json) of the received directory. Other files are ignored.
+// All problems found in the files are reported together, one per line.
+func teamsLoad(teamsPath string) ([]*Team, error) {
+	files, err := os.ReadDir(teamsPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, dirEntry := range files {
-		filePath := TEAMS_PATH + dirEntry.Name()
-		raw, err := util.ReadFile(filePath)
+	teams := make([]*Team, 0, len(files))
+	problems := []string{}
+	teamFilePaths := make(map[string]string)
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Unable to open team [%s]: %v\n", filePath, err)
-			return err
+	for _, dirEntry := range files {
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != ".json" {
+			continue
 		}
 
-		var team Team
-		err = json.Unmarshal(raw, &team)
+		filePath := filepath.Join(teamsPath, dirEntry.Name())
+		team, fileProblems := teamFileLoad(filePath)
+		problems = append(problems, fileProblems...)
+		if team == nil {
+			continue
+		}
 
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Unable to parse team [%s]: %v\n", filePath, err)
-			return err
+		if otherFilePath, ok := teamFilePaths[team.Name]; ok {
+			problems = append(problems, fmt.Sprintf("[%s] field [Name]: team [%s] is also defined in [%s]", filePath, team.Name, otherFilePath))
+			continue
 		}
+		teamFilePaths[team.Name] = filePath
 
-		team.DynamicAttributes.LastFixtures = make([]*Fixture, 0)
-		team.DynamicAttributes.Morale = 5
-		team.DynamicAttributes.PhysicalCondition = 5
+		teams = append(teams, team)
+	}
 
-		teams[team.Name] = &team
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid team files in [%s]:\n\t%s", teamsPath, strings.Join(problems, "\n\t"))
 	}
 
-	return nil
-}
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("no team files (*.json) in [%s]", teamsPath)
+	}
 
-func teamsGetWithName(name string) *Team {
-	return teams[name]
+	return teams, nil
 }
 
-func teamsGet() map[string]*Team {
-	return teams
-}
+// Loads and validates a team file: only known fields, all of them but Country present, attributes in the 0-10 range.
+// Returns nil and the problems found if the file is invalid.
+func teamFileLoad(filePath string) (*Team, []string) {
+	raw, err := util.ReadFile(filePath)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("[%s]: %v", filePath, err)}
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("[%s]: unable to parse: %v", filePath, err)}
+	}
+
+	problems := []string{}
+
+	unknownFields := []string{}
+	for field := range fields {
+		if !slices.Contains(teamFileFields, field) {
+			unknownFields = append(unknownFields, field)
+		}
+	}
+	sort.Strings(unknownFields)
+	for _, field := range unknownFields {
+		problems = append(problems, fmt.Sprintf("[%s] field [%s]: unknown field (fields are %s)", filePath, field, strings.Join(teamFileFields, ", ")))
+	}
 
-func teamsGetAllNames() []string {
-	names := make([]string, 0, len(teams))
-	for name := range teams {
-		names = append(names, name)
+	var file teamFile
+	values := map[string]interface{}{
+		"Name":       &file.Name,
+		"Country":    &file.Country,
+		"Attack":     &file.Attack,
+		"Midfield":   &file.Midfield,
+		"Defense":    &file.Defense,
+		"HomeFactor": &file.HomeFactor,
 	}
-	return names
+
+	for _, field := range teamFileFields {
+		value, ok := fields[field]
+		if !ok {
+			if field != "Country" {
+				problems = append(problems, fmt.Sprintf("[%s] field [%s]: missing", filePath, field))
+			}
+			continue
+		}
+
+		err = json.Unmarshal(value, values[field])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("[%s] field [%s]: invalid value %s", filePath, field, string(value)))
+			continue
+		}
+
+		if attribute, ok := values[field].(*float64); ok && (*attribute < 0 || *attribute > 10) {
+			problems = append(problems, fmt.Sprintf("[%s] field [%s]: value [%g] out of the 0-10 range", filePath, field, *attribute))
+		}
+		if field == "Name" && strings.TrimSpace(file.Name) == "" {
+			problems = append(problems, fmt.Sprintf("[%s] field [Name]: empty", filePath))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	team := Team{
+		Name:       file.Name,
+		Country:    file.Country,
+		Attack:     file.Attack,
+		Midfield:   file.Midfield,
+		Defense:    file.Defense,
+		HomeFactor: file.HomeFactor,
+		filePath:   filePath,
+	}
+	team.resetDynamicAttributes()
+
+	return &team, nil
 }
 
 func teamsGetDynamicAttributeMetadata() []AttributeType {
@@ -114,18 +222,18 @@ func teamsGetDynamicAttributeMetadata() []AttributeType {
 	return dynamicAttributesMetadata
 }
 
-func (t *Team) generateGptBasedRandomEvent(gptApiKey string) (string, error) {
+func (t *Team) generateGptBasedRandomEvent(gptApiKey string, rng *util.Rng) (string, error) {
 	dynamicAttributesMetadatas := teamsGetDynamicAttributeMetadata()
-	randomPos := util.RandomInt(len(dynamicAttributesMetadatas))
+	randomPos := util.RandomInt(rng, len(dynamicAttributesMetadatas))
 	attributeType := dynamicAttributesMetadatas[randomPos]
-	valueDiff := util.RandomValueFromNormalDistribution(0.0, 4.0)
+	valueDiff := util.RandomValueFromNormalDistribution(rng, 0.0, 4.0)
 
 	err := t.changeDynamicAttribute(attributeType, valueDiff)
 	if err != nil {
 		return "", err
 	}
 
-	msg, err := gpt.GptRetrieveMessage(gptApiKey, t.Name, attributeType.Name, attributeType.Description, valueDiff)
+	msg, err := gpt.GptRetrieveMessage(rng, gptApiKey, t.Name, attributeType.Name, attributeType.Description, valueDiff)
 
 	signal := '+'
 	if valueDiff < 0 {
@@ -135,6 +243,27 @@ func (t *Team) generateGptBasedRandomEvent(gptApiKey string) (string, error) {
 	return fullMsg, err
 }
 
+func (t *Team) country() string {
+	if t.Country == "" {
+		return DEFAULT_TEAM_COUNTRY
+	}
+	return t.Country
+}
+
+// Sum of the static attributes, used to seed teams when no previous results are available
+func (t *Team) rating() float64 {
+	return t.Attack + t.Midfield + t.Defense
+}
+
+func (t *Team) resetDynamicAttributes() {
+	t.DynamicAttributes.LastFixtures = make([]*Fixture, 0)
+	t.DynamicAttributes.Morale = 5
+	t.DynamicAttributes.PhysicalCondition = 5
+	t.DynamicAttributes.YellowCards = make(map[string]int)
+	t.DynamicAttributes.Suspensions = make(map[string]int)
+	t.DynamicAttributes.Elo = t.eloRatingFromAttributes()
+}
+
 func (t *Team) changeDynamicAttribute(attributeType AttributeType, valueDiff float64) error {
 	if attributeType.Name == TEAM_DYNAMIC_ATTRIBUTE_MORALE_NAME {
 		t.changeMorale(valueDiff)
@@ -156,7 +285,7 @@ func (t *Team) changePhysicalCondition(physicalCondDiff float64) {
 	t.DynamicAttributes.PhysicalCondition = util.Clamp(t.DynamicAttributes.PhysicalCondition, 0, 10)
 }
 
-func (t *Team) updateDynamicAttributes(playedFixture *Fixture) error {
+func (t *Team) updateDynamicAttributes(playedFixture *Fixture, rng *util.Rng) error {
 	// Not very performant, but shouldn't matter...
 	slices.Reverse(t.DynamicAttributes.LastFixtures)
 	t.DynamicAttributes.LastFixtures = append(t.DynamicAttributes.LastFixtures, playedFixture)
@@ -175,7 +304,24 @@ func (t *Team) updateDynamicAttributes(playedFixture *Fixture) error {
 	// to ensure that the match results will continue having a meaningful impact on the morale update.
 	moraleNormalMean := float64(goalDiff) * MORALE_UPDATE_STDDEV
 
-	t.changeMorale(util.RandomValueFromNormalDistribution(moraleNormalMean, MORALE_UPDATE_STDDEV))
-	t.changePhysicalCondition(util.RandomValueFromNormalDistribution(0.0, PHYSICAL_CONDITION_UPDATE_STDDEV))
+	t.changeMorale(util.RandomValueFromNormalDistribution(rng, moraleNormalMean, MORALE_UPDATE_STDDEV))
+	t.changePhysicalCondition(util.RandomValueFromNormalDistribution(rng, -PHYSICAL_CONDITION_MATCH_DRAIN, PHYSICAL_CONDITION_UPDATE_STDDEV))
+	t.updateDiscipline(playedFixture)
 	return nil
 }
+
+// Recovers the physical condition after the received rest days (-1 if unknown), exponentially approaching the rested condition.
+// Teams above it (e.g. after a GPT event) are not affected.
+func (t *Team) recoverPhysicalCondition(restDays int) {
+	if restDays < 0 {
+		restDays = PHYSICAL_CONDITION_DEFAULT_REST_DAYS
+	}
+
+	missing := PHYSICAL_CONDITION_RESTED - t.DynamicAttributes.PhysicalCondition
+	if missing <= 0 {
+		return
+	}
+
+	recovered := missing * (1 - math.Exp(-float64(restDays)/PHYSICAL_CONDITION_RECOVERY_DAYS))
+	t.changePhysicalCondition(recovered)
+}
@@ -0,0 +1,140 @@
+package simulation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestTeamsLoad(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"a.json":    `{"Name": "A", "Attack": 7, "Midfield": 6, "Defense": 5.5, "HomeFactor": 8}`,
+		"b.json":    `{"Name": "B", "Country": "Argentina", "Attack": 0, "Midfield": 10, "Defense": 5, "HomeFactor": 5}`,
+		"README.md": "Not a team file",
+	})
+	err := os.Mkdir(filepath.Join(dir, "backup.json"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err := teamsLoad(dir)
+	if err != nil {
+		t.Fatalf("unable to load teams: %v", err)
+	}
+	if len(teams) != 2 {
+		t.Fatalf("%d teams were loaded, expected 2", len(teams))
+	}
+	for _, team := range teams {
+		if team.Name == "A" && (team.Attack != 7 || team.Midfield != 6 || team.Defense != 5.5 || team.HomeFactor != 8 || team.country() != "Brazil") {
+			t.Errorf("team A was loaded as %+v", *team)
+		}
+		if team.Name == "B" && team.country() != "Argentina" {
+			t.Errorf("team B is from [%s], expected Argentina", team.country())
+		}
+	}
+}
+
+// All problems of all files are reported together, each with its file and field. Files are read in name order.
+func TestTeamsLoadReportsAllProblems(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"a.json":          `{"Name": "A", "Attack": 7, "Midfield": 6, "Defense": 5, "HomeFactor": 8}`,
+		"z-copy.json":     `{"Name": "A", "Attack": 7, "Midfield": 6, "Defense": 5, "HomeFactor": 8}`,
+		"unknown.json":    `{"Name": "B", "Attack": 7, "Midfield": 6, "Defense": 5, "HomeFactor": 8, "Atack": 3}`,
+		"missing.json":    `{"Name": "C", "Midfield": 6, "Defense": 5, "HomeFactor": 8}`,
+		"range.json":      `{"Name": "D", "Attack": 11, "Midfield": 6, "Defense": -1, "HomeFactor": 8}`,
+		"empty-name.json": `{"Name": " ", "Attack": 7, "Midfield": 6, "Defense": 5, "HomeFactor": 8}`,
+		"type.json":       `{"Name": "E", "Attack": "high", "Midfield": 6, "Defense": 5, "HomeFactor": 8}`,
+		"syntax.json":     `{"Name": "F",`,
+	})
+
+	_, err := teamsLoad(dir)
+	if err == nil {
+		t.Fatalf("invalid team files were accepted")
+	}
+
+	expectedProblems := []string{
+		"[" + filepath.Join(dir, "z-copy.json") + "] field [Name]: team [A] is also defined in [" + filepath.Join(dir, "a.json") + "]",
+		"[" + filepath.Join(dir, "unknown.json") + "] field [Atack]: unknown field",
+		"[" + filepath.Join(dir, "missing.json") + "] field [Attack]: missing",
+		"[" + filepath.Join(dir, "range.json") + "] field [Attack]: value [11] out of the 0-10 range",
+		"[" + filepath.Join(dir, "range.json") + "] field [Defense]: value [-1] out of the 0-10 range",
+		"[" + filepath.Join(dir, "empty-name.json") + "] field [Name]: empty",
+		"[" + filepath.Join(dir, "type.json") + "] field [Attack]: invalid value",
+		"[" + filepath.Join(dir, "syntax.json") + "]: unable to parse",
+	}
+	for _, problem := range expectedProblems {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem [%s] was not reported in:\n%v", problem, err)
+		}
+	}
+	if strings.Contains(err.Error(), "["+filepath.Join(dir, "a.json")+"] ") {
+		t.Errorf("valid file was reported in:\n%v", err)
+	}
+}
+
+func TestTeamsLoadWithoutTeamFiles(t *testing.T) {
+	if _, err := teamsLoad(writeTestFiles(t, map[string]string{"README.md": ""})); err == nil {
+		t.Errorf("directory without team files was accepted")
+	}
+}
+
+// With an odd number of teams, each team rests once in each half of the season and meets every other team at home and away
+func TestScheduleWithBye(t *testing.T) {
+	teamNames := []string{"A", "B", "C", "D", "E"}
+	if _, err := generateSchedule(teamNames, util.NewRng(1)); err == nil {
+		t.Fatalf("schedule without byes was generated for an odd number of teams")
+	}
+
+	schedule, err := generateScheduleWithBye(teamNames, util.NewRng(1))
+	if err != nil {
+		t.Fatalf("unable to generate schedule: %v", err)
+	}
+	if len(schedule.rounds) != 2*len(teamNames) {
+		t.Fatalf("%d rounds, expected %d", len(schedule.rounds), 2*len(teamNames))
+	}
+
+	rests := make(map[string]int)
+	pairings := make(map[[2]string]int)
+	for i, round := range schedule.rounds {
+		playing := make(map[string]bool)
+		for _, fixture := range round.fixtures {
+			if playing[fixture.homeTeam] || playing[fixture.awayTeam] || fixture.homeTeam == BYE_TEAM_NAME || fixture.awayTeam == BYE_TEAM_NAME {
+				t.Errorf("round %d: invalid fixture %s x %s", i+1, fixture.homeTeam, fixture.awayTeam)
+			}
+			playing[fixture.homeTeam] = true
+			playing[fixture.awayTeam] = true
+			pairings[[2]string{fixture.homeTeam, fixture.awayTeam}] += 1
+		}
+		for _, name := range teamNames {
+			if !playing[name] {
+				rests[name] += 1
+			}
+		}
+	}
+
+	for _, name := range teamNames {
+		if rests[name] != 2 {
+			t.Errorf("team %s rests in %d rounds, expected 2", name, rests[name])
+		}
+		for _, other := range teamNames {
+			if name != other && pairings[[2]string{name, other}] != 1 {
+				t.Errorf("%s x %s is played %d times, expected once", name, other, pairings[[2]string{name, other}])
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TIE_BREAK_POINTS          = "points"
+	TIE_BREAK_WINS            = "wins"
+	TIE_BREAK_GOAL_DIFFERENCE = "goal-difference"
+	TIE_BREAK_GOALS_FOR       = "goals-for"
+	TIE_BREAK_HEAD_TO_HEAD    = "head-to-head"
+	TIE_BREAK_RED_CARDS       = "red-cards"
+	TIE_BREAK_YELLOW_CARDS    = "yellow-cards"
+	TIE_BREAK_DRAWING_OF_LOTS = "drawing-of-lots"
+)
+
+// Value of a team under a criterion, among the group of teams tied on the previous criteria, considering the fixtures
+// until the received round. Teams with higher values rank ahead.
+type TieBreakValue func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int
+
+type TieBreakCriterion struct {
+	Name  string
+	Value TieBreakValue
+}
+
+var tieBreakCriteria = []TieBreakCriterion{
+	{TIE_BREAK_POINTS, func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int { return t.Points }},
+	{TIE_BREAK_WINS, func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int { return t.Won }},
+	{TIE_BREAK_GOAL_DIFFERENCE, func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int { return t.GoalsDiff }},
+	{TIE_BREAK_GOALS_FOR, func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int { return t.GoalsFor }},
+	{TIE_BREAK_HEAD_TO_HEAD, headToHeadValue},
+	// Fewer cards rank ahead
+	{TIE_BREAK_RED_CARDS, func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int { return -t.RedCards }},
+	{TIE_BREAK_YELLOW_CARDS, func(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int { return -t.YellowCards }},
+	{TIE_BREAK_DRAWING_OF_LOTS, drawingOfLotsValue},
+}
+
+// Official order of the CBF regulations
+var defaultTieBreakChain = []string{
+	TIE_BREAK_POINTS,
+	TIE_BREAK_WINS,
+	TIE_BREAK_GOAL_DIFFERENCE,
+	TIE_BREAK_GOALS_FOR,
+	TIE_BREAK_HEAD_TO_HEAD,
+	TIE_BREAK_RED_CARDS,
+	TIE_BREAK_YELLOW_CARDS,
+	TIE_BREAK_DRAWING_OF_LOTS,
+}
+
+func tieBreakCriterionGetWithName(name string) (TieBreakCriterion, bool) {
+	for _, criterion := range tieBreakCriteria {
+		if criterion.Name == name {
+			return criterion, true
+		}
+	}
+	return TieBreakCriterion{}, false
+}
+
+func tieBreakCriteriaNames() []string {
+	names := make([]string, 0, len(tieBreakCriteria))
+	for _, criterion := range tieBreakCriteria {
+		names = append(names, criterion.Name)
+	}
+	return names
+}
+
+// Builds a tie-break chain from a comma-separated list of criteria names (the default chain is used if empty).
+// The drawing of lots is always the last criterion, so the chain always produces a strict order.
+func tieBreakChainParse(names string) ([]TieBreakCriterion, error) {
+	criteriaNames := defaultTieBreakChain
+	if strings.TrimSpace(names) != "" {
+		criteriaNames = strings.Split(names, ",")
+	}
+
+	chain := make([]TieBreakCriterion, 0, len(criteriaNames)+1)
+	hasDrawingOfLots := false
+
+	for _, name := range criteriaNames {
+		name = strings.TrimSpace(name)
+		criterion, ok := tieBreakCriterionGetWithName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tie-break criterion [%s] (available: %s)", name, strings.Join(tieBreakCriteriaNames(), ", "))
+		}
+		if hasDrawingOfLots {
+			return nil, fmt.Errorf("tie-break criterion [%s] comes after the drawing of lots, which is always decisive", name)
+		}
+		if name == TIE_BREAK_DRAWING_OF_LOTS {
+			hasDrawingOfLots = true
+		}
+		chain = append(chain, criterion)
+	}
+
+	if !hasDrawingOfLots {
+		criterion, _ := tieBreakCriterionGetWithName(TIE_BREAK_DRAWING_OF_LOTS)
+		chain = append(chain, criterion)
+	}
+
+	return chain, nil
+}
+
+// Sorts the team statistics with the season tie-break chain, considering the fixtures until the received round.
+// Each criterion is applied to the whole group of teams tied on the previous ones, so the order doesn't depend on the input order.
+// Returns the name of the criterion that separated each pair of adjacent teams.
+func (s *Season) rankTeamStatistics(teamStatistics []*TeamStatistic, roundIdx int) []string {
+	deciders := make([]string, max(len(teamStatistics)-1, 0))
+	s.rankTiedTeams(teamStatistics, roundIdx, 0, deciders)
+	return deciders
+}
+
+// Sorts a group of teams tied on the criteria before criterionIdx, then the subgroups still tied with the next criteria
+func (s *Season) rankTiedTeams(tied []*TeamStatistic, roundIdx int, criterionIdx int, deciders []string) {
+	if len(tied) < 2 || criterionIdx == len(s.tieBreakChain) {
+		return
+	}
+
+	criterion := s.tieBreakChain[criterionIdx]
+	values := make(map[string]int)
+	for _, teamStatistic := range tied {
+		values[teamStatistic.Name] = criterion.Value(s, roundIdx, tied, teamStatistic)
+	}
+
+	sort.SliceStable(tied, func(i, j int) bool {
+		return values[tied[i].Name] > values[tied[j].Name]
+	})
+
+	start := 0
+	for i := 1; i <= len(tied); i++ {
+		if i < len(tied) && values[tied[i].Name] == values[tied[start].Name] {
+			continue
+		}
+		if i < len(tied) {
+			deciders[i-1] = criterion.Name
+		}
+		s.rankTiedTeams(tied[start:i], roundIdx, criterionIdx+1, deciders[start:i-1])
+		start = i
+	}
+}
+
+// Goal difference of the team in the fixtures between the tied teams, as in a mini-league.
+// With two tied teams, it is the difference between the goals each one scored against the other.
+func headToHeadValue(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int {
+	tiedNames := make(map[string]bool)
+	for _, teamStatistic := range tied {
+		tiedNames[teamStatistic.Name] = true
+	}
+
+	goalDifference := 0
+	for i := 0; i <= roundIdx && i < len(s.schedule.rounds); i++ {
+		for _, f := range s.schedule.rounds[i].fixtures {
+			if !f.played || !tiedNames[f.homeTeam] || !tiedNames[f.awayTeam] {
+				continue
+			}
+			if f.homeTeam == t.Name {
+				goalDifference += f.homeTeamScore - f.awayTeamScore
+			} else if f.awayTeam == t.Name {
+				goalDifference += f.awayTeamScore - f.homeTeamScore
+			}
+		}
+	}
+	return goalDifference
+}
+
+// The drawing of lots is done once per season (see Season.drawLots), so this value is always consistent
+func drawingOfLotsValue(s *Season, roundIdx int, tied []*TeamStatistic, t *TeamStatistic) int {
+	return -s.drawingOfLotsPosition(t.Name)
+}
+
+// Draws the order used as the last tie-break criterion, recording it in the season
+func (s *Season) drawLots() {
+	names := s.teamsGetAllNames()
+	s.drawingOfLots = make([]string, 0, len(names))
+	for _, i := range s.rng.Perm(len(names)) {
+		s.drawingOfLots = append(s.drawingOfLots, names[i])
+	}
+}
+
+func (s *Season) drawingOfLotsPosition(teamName string) int {
+	for i, name := range s.drawingOfLots {
+		if name == teamName {
+			return i
+		}
+	}
+	return len(s.drawingOfLots)
+}
+
+func tieBreakChainNames(chain []TieBreakCriterion) []string {
+	names := make([]string, 0, len(chain))
+	for _, criterion := range chain {
+		names = append(names, criterion.Name)
+	}
+	return names
+}
+
+func (s *Season) printDrawingOfLots() {
+	fmt.Printf("Tie-break criteria: [%s]\n", strings.Join(tieBreakChainNames(s.tieBreakChain), ", "))
+	fmt.Printf("Drawing of lots: [%s]\n", strings.Join(s.drawingOfLots, ", "))
+}
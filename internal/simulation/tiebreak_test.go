@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+func newTestSeason(t *testing.T, teamNames []string, tieBreakers string) *Season {
+	t.Helper()
+	teams := []*Team{}
+	for _, name := range teamNames {
+		teams = append(teams, &Team{Name: name, Attack: 5, Midfield: 5, Defense: 5, HomeFactor: 5})
+	}
+
+	season, err := newSeasonWithoutSchedule(teams, util.NewRng(1))
+	if err != nil {
+		t.Fatalf("unable to create season: %v", err)
+	}
+	season.tieBreakChain, err = tieBreakChainParse(tieBreakers)
+	if err != nil {
+		t.Fatalf("invalid tie-break chain: %v", err)
+	}
+	season.drawingOfLots = teamNames
+	return season
+}
+
+func newPlayedFixture(homeTeam string, awayTeam string, homeTeamScore int, awayTeamScore int) *Fixture {
+	fixture := newFixture(homeTeam, awayTeam)
+	fixture.homeTeamScore = homeTeamScore
+	fixture.awayTeamScore = awayTeamScore
+	fixture.played = true
+	return fixture
+}
+
+func teamStatisticsNames(teamStatistics []*TeamStatistic) []string {
+	names := []string{}
+	for _, teamStatistic := range teamStatistics {
+		names = append(names, teamStatistic.Name)
+	}
+	return names
+}
+
+func TestTieBreakChainParse(t *testing.T) {
+	chain, err := tieBreakChainParse("")
+	if err != nil {
+		t.Fatalf("default chain: %v", err)
+	}
+	if !reflect.DeepEqual(tieBreakChainNames(chain), defaultTieBreakChain) {
+		t.Errorf("default chain is %v, expected %v", tieBreakChainNames(chain), defaultTieBreakChain)
+	}
+
+	chain, err = tieBreakChainParse("points, goals-for")
+	if err != nil {
+		t.Fatalf("custom chain: %v", err)
+	}
+	expected := []string{TIE_BREAK_POINTS, TIE_BREAK_GOALS_FOR, TIE_BREAK_DRAWING_OF_LOTS}
+	if !reflect.DeepEqual(tieBreakChainNames(chain), expected) {
+		t.Errorf("custom chain is %v, expected %v", tieBreakChainNames(chain), expected)
+	}
+
+	for _, invalid := range []string{"points,unknown", "points,drawing-of-lots,wins"} {
+		if _, err := tieBreakChainParse(invalid); err == nil {
+			t.Errorf("chain [%s] was accepted", invalid)
+		}
+	}
+}
+
+func TestTieBreakChainOrder(t *testing.T) {
+	season := newTestSeason(t, []string{"A", "B", "C", "D", "E"}, "")
+	teamStatistics := []*TeamStatistic{
+		{Name: "A", Points: 10, Won: 3, GoalsDiff: 2, GoalsFor: 5, YellowCards: 4},
+		{Name: "B", Points: 10, Won: 3, GoalsDiff: 2, GoalsFor: 5, YellowCards: 4},
+		{Name: "C", Points: 10, Won: 3, GoalsDiff: 2, GoalsFor: 5, YellowCards: 2},
+		{Name: "D", Points: 10, Won: 3, GoalsDiff: 3, GoalsFor: 5, RedCards: 3},
+		{Name: "E", Points: 12, Won: 3},
+	}
+
+	deciders := season.rankTeamStatistics(teamStatistics, -1)
+
+	expectedNames := []string{"E", "D", "C", "A", "B"}
+	if names := teamStatisticsNames(teamStatistics); !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("order is %v, expected %v", names, expectedNames)
+	}
+	expectedDeciders := []string{TIE_BREAK_POINTS, TIE_BREAK_GOAL_DIFFERENCE, TIE_BREAK_YELLOW_CARDS, TIE_BREAK_DRAWING_OF_LOTS}
+	if !reflect.DeepEqual(deciders, expectedDeciders) {
+		t.Errorf("deciders are %v, expected %v", deciders, expectedDeciders)
+	}
+}
+
+// A beats B, B beats C and C beats A: compared in pairs, head-to-head is a cycle,
+// so the order must come from the mini-league of the three teams, whatever the input order
+func TestHeadToHeadCycleIsRankedAsMiniLeague(t *testing.T) {
+	season := newTestSeason(t, []string{"A", "B", "C", "D"}, "points,head-to-head")
+	season.schedule.rounds = []*Round{
+		{fixtures: []*Fixture{newPlayedFixture("A", "B", 3, 0), newPlayedFixture("C", "D", 0, 0)}},
+		{fixtures: []*Fixture{newPlayedFixture("B", "C", 1, 0), newPlayedFixture("D", "A", 0, 0)}},
+		{fixtures: []*Fixture{newPlayedFixture("C", "A", 1, 0), newPlayedFixture("B", "D", 0, 0)}},
+	}
+
+	// Each of A, B and C has 4 points. Mini-league goal difference: A +2, C 0, B -2.
+	expectedNames := []string{"A", "C", "B", "D"}
+	for _, inputOrder := range [][]string{{"A", "B", "C", "D"}, {"B", "C", "A", "D"}, {"C", "A", "B", "D"}, {"C", "B", "A", "D"}, {"D", "B", "A", "C"}} {
+		standingsMap := fillStandingsMapUntilRound(season, 2)
+		teamStatistics := []*TeamStatistic{}
+		for _, name := range inputOrder {
+			teamStatistics = append(teamStatistics, standingsMap[name])
+		}
+
+		deciders := season.rankTeamStatistics(teamStatistics, 2)
+
+		if names := teamStatisticsNames(teamStatistics); !reflect.DeepEqual(names, expectedNames) {
+			t.Errorf("input order %v: order is %v, expected %v", inputOrder, names, expectedNames)
+		}
+		expectedDeciders := []string{TIE_BREAK_HEAD_TO_HEAD, TIE_BREAK_HEAD_TO_HEAD, TIE_BREAK_POINTS}
+		if !reflect.DeepEqual(deciders, expectedDeciders) {
+			t.Errorf("input order %v: deciders are %v, expected %v", inputOrder, deciders, expectedDeciders)
+		}
+	}
+}
+
+func TestHeadToHeadIgnoresLaterRounds(t *testing.T) {
+	season := newTestSeason(t, []string{"A", "B", "C", "D"}, "points,head-to-head")
+	season.schedule.rounds = []*Round{
+		{fixtures: []*Fixture{newPlayedFixture("A", "C", 1, 0), newPlayedFixture("B", "D", 1, 0)}},
+		{fixtures: []*Fixture{newPlayedFixture("A", "B", 0, 2), newPlayedFixture("C", "D", 0, 0)}},
+	}
+
+	// After the first round, B's win over A in the second round is unknown, so the drawing of lots decides
+	teamStatistics, deciders := generateTeamStatisticsUntilRound(season, 0)
+	if names := teamStatisticsNames(teamStatistics); names[0] != "A" || names[1] != "B" {
+		t.Errorf("order after the first round is %v, expected A ahead of B", names)
+	}
+	if deciders[0] != TIE_BREAK_DRAWING_OF_LOTS {
+		t.Errorf("A and B were separated by [%s] after the first round, expected [%s]", deciders[0], TIE_BREAK_DRAWING_OF_LOTS)
+	}
+
+	teamStatistics, _ = generateTeamStatisticsUntilRound(season, 1)
+	if names := teamStatisticsNames(teamStatistics); names[0] != "B" {
+		t.Errorf("order after the second round is %v, expected B first", names)
+	}
+}
@@ -2,13 +2,15 @@ package simulation
 
 import (
 	"fmt"
-	"math/rand"
+	"time"
 
 	"github.com/felipeek/brasileirao-simulation/internal/util"
 )
 
 type Round struct {
 	fixtures []*Fixture
+	// First day of the round. Zero if the schedule has no dates.
+	date time.Time
 }
 
 type Schedule struct {
@@ -18,9 +20,38 @@ type Schedule struct {
 	rounds          []*Round
 }
 
-func generateSchedule(teams map[string]*Team) (Schedule, error) {
-	if len(teams)%2 != 0 {
-		return Schedule{}, fmt.Errorf("number of teams must be pair")
+// Placeholder opponent of the team that rests in each round. Team files can't have an empty name.
+const BYE_TEAM_NAME = ""
+
+// Generates a double round-robin schedule in which, if the number of teams is odd, one team rests in each round
+func generateScheduleWithBye(teamNames []string, rng *util.Rng) (Schedule, error) {
+	if len(teamNames)%2 == 0 {
+		return generateSchedule(teamNames, rng)
+	}
+
+	schedule, err := generateSchedule(append(append([]string{}, teamNames...), BYE_TEAM_NAME), rng)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	for _, round := range schedule.rounds {
+		fixtures := make([]*Fixture, 0, len(round.fixtures)-1)
+		for _, fixture := range round.fixtures {
+			if fixture.homeTeam != BYE_TEAM_NAME && fixture.awayTeam != BYE_TEAM_NAME {
+				fixtures = append(fixtures, fixture)
+			}
+		}
+		round.fixtures = fixtures
+	}
+
+	return schedule, nil
+}
+
+// Generates a double round-robin schedule for the received teams.
+// The order of teamNames must be deterministic, so the schedule only depends on the rng.
+func generateSchedule(teamNames []string, rng *util.Rng) (Schedule, error) {
+	if len(teamNames)%2 != 0 {
+		return Schedule{}, fmt.Errorf("odd number of teams [%d]: add or remove a team, or let one team rest in each round (-bye)", len(teamNames))
 	}
 
 	schedule := Schedule{}
@@ -32,14 +63,12 @@ func generateSchedule(teams map[string]*Team) (Schedule, error) {
 
 	// Create a slice containing all available teams
 	// It will be used to construct the schedule
-	for teamName, _ := range teams {
-		roundRobinTeams = append(roundRobinTeams, teamName)
-	}
+	roundRobinTeams = append(roundRobinTeams, teamNames...)
 
 	// Randomize array (simple algorithm, not very good randomization)
 	for i := 0; i < 2*len(roundRobinTeams); i++ {
-		r1 := rand.Int() % len(roundRobinTeams)
-		r2 := rand.Int() % len(roundRobinTeams)
+		r1 := util.RandomInt(rng, len(roundRobinTeams))
+		r2 := util.RandomInt(rng, len(roundRobinTeams))
 
 		cached := roundRobinTeams[r1]
 		roundRobinTeams[r1] = roundRobinTeams[r2]
@@ -53,8 +82,7 @@ func generateSchedule(teams map[string]*Team) (Schedule, error) {
 		round := Round{}
 
 		for j := 0; j < len(roundRobinTeams)/2; j++ {
-			fixture := Fixture{roundRobinTeams[j], roundRobinTeams[len(roundRobinTeams)-1-j], -1, -1, false}
-			round.fixtures = append(round.fixtures, &fixture)
+			round.fixtures = append(round.fixtures, newFixture(roundRobinTeams[j], roundRobinTeams[len(roundRobinTeams)-1-j]))
 		}
 
 		schedule.rounds = append(schedule.rounds, &round)
@@ -70,7 +98,7 @@ func generateSchedule(teams map[string]*Team) (Schedule, error) {
 
 	homeAwayCountMap := make(map[string]int)
 
-	for teamName, _ := range teams {
+	for _, teamName := range teamNames {
 		homeAwayCountMap[teamName] = 0
 	}
 
@@ -110,8 +138,7 @@ func generateSchedule(teams map[string]*Team) (Schedule, error) {
 		counterpartRound := Round{}
 
 		for _, fixture := range existingRound.fixtures {
-			counterpartFixture := Fixture{fixture.awayTeam, fixture.homeTeam, -1, -1, false}
-			counterpartRound.fixtures = append(counterpartRound.fixtures, &counterpartFixture)
+			counterpartRound.fixtures = append(counterpartRound.fixtures, newFixture(fixture.awayTeam, fixture.homeTeam))
 		}
 
 		schedule.rounds = append(schedule.rounds, &counterpartRound)
@@ -120,72 +147,147 @@ func generateSchedule(teams map[string]*Team) (Schedule, error) {
 	return schedule, nil
 }
 
-func (r *Round) playFixtures() error {
-	for _, fixture := range r.fixtures {
-		err := fixture.play()
+// Generates a schedule in which each team plays the teams of its own group, all groups playing in the same rounds.
+// With a single leg, only the first half of each group round-robin is kept.
+func generateWithinGroupsSchedule(groups [][]string, numLegs int, rng *util.Rng) (Schedule, error) {
+	schedule := Schedule{currentRoundIdx: -1, nextRoundIdx: 0}
+
+	for _, group := range groups {
+		groupSchedule, err := generateSchedule(group, rng)
 		if err != nil {
-			return err
+			return Schedule{}, err
+		}
+
+		numRounds := len(groupSchedule.rounds) * numLegs / 2
+		for i, round := range groupSchedule.rounds[:numRounds] {
+			if i == len(schedule.rounds) {
+				schedule.rounds = append(schedule.rounds, &Round{})
+			}
+			schedule.rounds[i].fixtures = append(schedule.rounds[i].fixtures, round.fixtures...)
 		}
 	}
 
-	return nil
+	return schedule, nil
 }
 
-func (s *Schedule) playAllFixtures() error {
-	for _, round := range s.rounds {
-		for _, fixture := range round.fixtures {
-			if !fixture.played {
-				err := fixture.play()
-				if err != nil {
-					return err
+// Generates a schedule in which each team plays only the teams of the other groups, as in the Paulistao.
+// The groups are paired using a round-robin over the groups, and each pairing is played in as many rounds
+// as the group size, shifting the opponents every round, so all teams play in all rounds.
+func generateCrossGroupSchedule(groups [][]string, numLegs int) (Schedule, error) {
+	if len(groups)%2 != 0 {
+		return Schedule{}, fmt.Errorf("cross-group fixtures need an even number of groups")
+	}
+
+	groupIdxs := []int{}
+	for i := range groups {
+		groupIdxs = append(groupIdxs, i)
+	}
+
+	schedule := Schedule{currentRoundIdx: -1, nextRoundIdx: 0}
+
+	for i := 0; i < len(groups)-1; i++ {
+		for shift := 0; shift < len(groups[0]); shift++ {
+			round := Round{}
+
+			for j := 0; j < len(groupIdxs)/2; j++ {
+				firstGroup := groups[groupIdxs[j]]
+				secondGroup := groups[groupIdxs[len(groupIdxs)-1-j]]
+
+				for k, firstTeam := range firstGroup {
+					secondTeam := secondGroup[(k+shift)%len(secondGroup)]
+					// Alternate the home team, so all teams have a balanced number of home and away games
+					if (shift+i)%2 == 0 {
+						round.fixtures = append(round.fixtures, newFixture(firstTeam, secondTeam))
+					} else {
+						round.fixtures = append(round.fixtures, newFixture(secondTeam, firstTeam))
+					}
 				}
 			}
-		}
-	}
 
-	s.currentRoundIdx = len(s.rounds) - 1
-	s.nextRoundIdx = -1
-	s.finished = true
-	return nil
-}
+			schedule.rounds = append(schedule.rounds, &round)
+		}
 
-func (s *Schedule) playNextRoundFixtures() error {
-	if s.finished {
-		return nil
+		// Same rotation used by generateSchedule, keeping the first group static
+		last := groupIdxs[len(groupIdxs)-1]
+		copy(groupIdxs[2:], groupIdxs[1:len(groupIdxs)-1])
+		groupIdxs[1] = last
 	}
 
-	round := s.rounds[s.nextRoundIdx]
-	err := round.playFixtures()
-	if err != nil {
-		return err
+	if numLegs == 2 {
+		numHalfRounds := len(schedule.rounds)
+		for i := 0; i < numHalfRounds; i++ {
+			counterpartRound := Round{}
+			for _, fixture := range schedule.rounds[i].fixtures {
+				counterpartRound.fixtures = append(counterpartRound.fixtures, newFixture(fixture.awayTeam, fixture.homeTeam))
+			}
+			schedule.rounds = append(schedule.rounds, &counterpartRound)
+		}
 	}
 
+	return schedule, nil
+}
+
+// Moves to the next round, after all fixtures of the current one were played
+func (s *Schedule) advance() {
 	s.currentRoundIdx += 1
 	s.nextRoundIdx += 1
 	if s.nextRoundIdx == len(s.rounds) {
 		s.nextRoundIdx = -1
 		s.finished = true
 	}
-	return nil
+}
+
+// Spreads the matchdays of a competition evenly over the league season,
+// returning the league round after which each matchday is played
+func spreadMatchdaysOverLeague(numLeagueRounds int, numMatchdays int) []int {
+	matchdaysSchedule := make([]int, 0, numMatchdays)
+	for i := 0; i < numMatchdays; i++ {
+		matchdaysSchedule = append(matchdaysSchedule, (i+1)*numLeagueRounds/(numMatchdays+1)-1)
+	}
+	return matchdaysSchedule
+}
+
+func (r *Round) allFixturesPlayed() bool {
+	for _, fixture := range r.fixtures {
+		if !fixture.played {
+			return false
+		}
+	}
+	return true
 }
 
 func (r *Round) print(enableTerminalColors bool) {
 	for _, fixture := range r.fixtures {
-		fmt.Printf("\t%s %d x %d %s\n", fixture.homeTeam, fixture.homeTeamScore, fixture.awayTeamScore, fixture.awayTeam)
+		kickoff := formatKickoff(fixture.kickoff)
+		if kickoff != "" {
+			kickoff += "  "
+		}
+		fmt.Printf("\t%s%s %d x %d %s\n", kickoff, fixture.homeTeam, fixture.homeTeamScore, fixture.awayTeamScore, fixture.awayTeam)
+		if len(fixture.goals) > 0 {
+			fmt.Printf("\t\t%s\n", fixture.formatGoals())
+		}
+	}
+}
+
+func (r *Round) printHeader(roundIdx int) {
+	if r.date.IsZero() {
+		fmt.Printf("Round [%d]\n", roundIdx+1)
+	} else {
+		fmt.Printf("Round [%d] - %s\n", roundIdx+1, formatDate(r.date))
 	}
 }
 
 func (s *Schedule) print(enableTerminalColors bool) {
 	for i, round := range s.rounds {
-		fmt.Printf("Round [%d]\n", i+1)
+		round.printHeader(i)
 		round.print(enableTerminalColors)
 	}
 }
 
 func (s *Schedule) printLastPlayedRound(enableTerminalColors bool) {
 	if s.currentRoundIdx >= 0 {
-		fmt.Printf("Round [%d]\n", s.currentRoundIdx+1)
 		round := s.rounds[s.currentRoundIdx]
+		round.printHeader(s.currentRoundIdx)
 		round.print(enableTerminalColors)
 	}
 }
@@ -0,0 +1,160 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bit101/go-ansi"
+	"github.com/felipeek/brasileirao-simulation/internal/util"
+)
+
+// A range of final positions with a special meaning, e.g. qualification to a competition or relegation.
+// The Libertadores and Sudamericana spots change every year, so zones can be loaded from a configuration file.
+type Zone struct {
+	Name      string
+	FirstRank int
+	LastRank  int
+	Color     string
+	Label     string
+}
+
+var zoneColors = map[string]ansi.AnsiColor{
+	"red":     ansi.BoldRed,
+	"green":   ansi.BoldGreen,
+	"yellow":  ansi.BoldYellow,
+	"blue":    ansi.BoldBlue,
+	"purple":  ansi.BoldPurple,
+	"cyan":    ansi.BoldCyan,
+	"white":   ansi.BoldWhite,
+	"default": ansi.Default,
+}
+
+// Color of the positions that don't belong to any zone
+const NO_ZONE_COLOR = "white"
+
+var defaultZones = []Zone{
+	{"Libertadores", 1, 4, "cyan", "Copa Libertadores (group stage)"},
+	{"Pre-Libertadores", 5, 6, "blue", "Copa Libertadores (qualifying stage)"},
+	{"Sudamericana", 7, 12, "yellow", "Copa Sudamericana"},
+	{"Relegation", 17, 20, "red", "Relegated to Serie B"},
+}
+
+// Loads the zones from a JSON file containing an array of zones. If zonesPath is empty, the default zones are returned.
+func zonesLoad(zonesPath string) ([]Zone, error) {
+	if zonesPath == "" {
+		return defaultZones, nil
+	}
+
+	raw, err := util.ReadFile(zonesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []Zone
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&zones)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse zones [%s]: %v", zonesPath, err)
+	}
+
+	err = zonesValidate(zones)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zones [%s]: %v", zonesPath, err)
+	}
+
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].FirstRank < zones[j].FirstRank
+	})
+
+	return zones, nil
+}
+
+func zonesValidate(zones []Zone) error {
+	names := make(map[string]bool)
+
+	for i, zone := range zones {
+		if zone.Name == "" {
+			return fmt.Errorf("zone %d has no name", i+1)
+		}
+		if names[zone.Name] {
+			return fmt.Errorf("zone [%s] is defined more than once", zone.Name)
+		}
+		names[zone.Name] = true
+
+		if zone.FirstRank < 1 || zone.LastRank < zone.FirstRank {
+			return fmt.Errorf("zone [%s] has an invalid rank range [%d-%d]", zone.Name, zone.FirstRank, zone.LastRank)
+		}
+		if _, ok := zoneColors[zone.Color]; !ok {
+			return fmt.Errorf("zone [%s] has an unknown color [%s] (available: %s)", zone.Name, zone.Color, strings.Join(zoneColorNames(), ", "))
+		}
+
+		for _, other := range zones[:i] {
+			if zone.FirstRank <= other.LastRank && other.FirstRank <= zone.LastRank {
+				return fmt.Errorf("zones [%s] and [%s] overlap", other.Name, zone.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func zoneColorNames() []string {
+	names := make([]string, 0, len(zoneColors))
+	for name := range zoneColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the zone containing the rank, or nil if the rank doesn't belong to any zone
+func zoneForRank(zones []Zone, rank int) *Zone {
+	for i := range zones {
+		if rank >= zones[i].FirstRank && rank <= zones[i].LastRank {
+			return &zones[i]
+		}
+	}
+	return nil
+}
+
+func zoneNameForRank(zones []Zone, rank int) string {
+	zone := zoneForRank(zones, rank)
+	if zone == nil {
+		return ""
+	}
+	return zone.Name
+}
+
+// Width of the zone column of the standings, which fits the header and the longest zone name
+func zonesNameWidth(zones []Zone) int {
+	width := len("Zone")
+	for _, zone := range zones {
+		width = max(width, len(zone.Name))
+	}
+	return width
+}
+
+func getRankPrintColor(zones []Zone, rank int) ansi.AnsiColor {
+	zone := zoneForRank(zones, rank)
+	if zone == nil {
+		return zoneColors[NO_ZONE_COLOR]
+	}
+	return zoneColors[zone.Color]
+}
+
+func printZonesLegend(zones []Zone, enableTerminalColors bool) {
+	fmt.Printf("Zones:")
+	for _, zone := range zones {
+		legend := fmt.Sprintf(" ■ %s (%d-%d): %s", zone.Name, zone.FirstRank, zone.LastRank, zone.Label)
+		if !enableTerminalColors {
+			fmt.Printf("%s", legend)
+		} else {
+			ansi.Printf(zoneColors[zone.Color], "%s", legend)
+		}
+	}
+	fmt.Println()
+}
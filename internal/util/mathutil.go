@@ -2,19 +2,18 @@ package util
 
 import (
 	"math"
-	"math/rand"
 )
 
 // Box-Muller transform.
-func RandomValueFromNormalDistribution(center, stddev float64) float64 {
-	u1 := rand.Float64()
-	u2 := rand.Float64()
+func RandomValueFromNormalDistribution(rng *Rng, center, stddev float64) float64 {
+	u1 := rng.Float64()
+	u2 := rng.Float64()
 	z0 := math.Sqrt(-2.0*math.Log(u1)) * math.Cos(2.0*math.Pi*u2)
 	return center + z0*stddev
 }
 
 // https://www.johndcook.com/blog/2010/06/14/generating-poisson-random-values/
-func PoissonKnuth(lambda float64) int {
+func PoissonKnuth(rng *Rng, lambda float64) int {
 	if lambda <= 0 {
 		return 0
 	}
@@ -25,7 +24,7 @@ func PoissonKnuth(lambda float64) int {
 
 	for p > L {
 		k++
-		u := rand.Float64()
+		u := rng.Float64()
 		p *= u
 	}
 
@@ -54,3 +53,39 @@ func AttenuateStrength(strength float64) float64 {
 func GetMultiplierFromContributionFactor(contribution, impact float64) float64 {
 	return math.Pow(1+impact, contribution-5)
 }
+
+// Arithmetic mean of the values, 0 if there are none
+func Average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// Picks an index with probability proportional to its weight. All weights must be non-negative, and at least one positive.
+func RandomWeightedIndex(rng *Rng, weights []float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	target := rng.Float64() * total
+	for i, weight := range weights {
+		if target < weight {
+			return i
+		}
+		target -= weight
+	}
+
+	// Floating point rounding, return the last index with a positive weight
+	for i := len(weights) - 1; i > 0; i-- {
+		if weights[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"math/rand/v2"
+)
+
+// Random number generator used by the whole simulation.
+// All random draws must go through it, so the same seed always produces the same season.
+type Rng struct {
+	*rand.Rand
+	source *rand.PCG
+}
+
+func NewRng(seed uint64) *Rng {
+	source := rand.NewPCG(seed, seed)
+	return &Rng{
+		Rand:   rand.New(source),
+		source: source,
+	}
+}
+
+// Serializes the internal state of the generator, so it can be restored later via UnmarshalBinary
+func (r *Rng) MarshalBinary() ([]byte, error) {
+	return r.source.MarshalBinary()
+}
+
+func (r *Rng) UnmarshalBinary(data []byte) error {
+	return r.source.UnmarshalBinary(data)
+}
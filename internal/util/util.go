@@ -3,7 +3,6 @@ package util
 import (
 	"io"
 	"math"
-	"math/rand"
 	"os"
 )
 
@@ -37,14 +36,14 @@ func Clamp(value, min, max float64) float64 {
 	return math.Max(min, math.Min(max, value))
 }
 
-func RandomInt(size int) int {
-	return rand.Int() % size
+func RandomInt(rng *Rng, size int) int {
+	return rng.IntN(size)
 }
 
-func RandomChoice(choices ...interface{}) interface{} {
+func RandomChoice(rng *Rng, choices ...interface{}) interface{} {
 	if len(choices) == 0 {
 		return nil // Retorna nil se não houver argumentos
 	}
-	randomIndex := rand.Intn(len(choices))
+	randomIndex := rng.IntN(len(choices))
 	return choices[randomIndex]
 }
@@ -2,20 +2,194 @@ package main
 
 import (
 	"flag"
-	"math/rand"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/felipeek/brasileirao-simulation/internal/simulation"
 )
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	if len(os.Args) > 1 && os.Args[1] == "montecarlo" {
+		monteCarlo(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "scoremodels" {
+		compareScoreModels(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "fit" {
+		fit(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		backtest(os.Args[2:])
+		return
+	}
 
 	nonInteractive := flag.Bool("non-interactive", false, "Run in non-interactive mode")
 	gptApiKey := flag.String("gpt-api-key", "", "GPT API Key")
 	disableTerminalColors := flag.Bool("disable-terminal-colors", false, "Disable colors in the terminal output")
+	seed := flag.Uint64("seed", 0, "Seed for the random number generator (if 0, a random seed is picked)")
+	resumeFile := flag.String("resume", "", "Resume a season previously saved in interactive mode")
+	fixturesFile := flag.String("fixtures", "", "CSV file with the real schedule and results so far (round,home,away,home score,away score)")
+	outputFormat := flag.String("output-format", "", "Export the final standings and schedule in this format (csv, json or markdown)")
+	outputFile := flag.String("output-file", "", "File to which the JSON or Markdown export is written (defaults to stdout)")
+	outputDir := flag.String("output-dir", "", "Directory to which the CSV export is written, as standings.csv and fixtures.csv")
+	tieBreakers := flag.String("tie-breakers", "", "Comma-separated tie-break criteria, in order (defaults to the CBF regulations: points,wins,goal-difference,goals-for,head-to-head,red-cards,yellow-cards,drawing-of-lots)")
+	scoreModel := flag.String("score-model", "", "Model that draws the score of each match: poisson (independent draws, default) or dixon-coles (more 0-0 and 1-1 draws)")
+	matchModel := flag.String("match-model", "", "Model that decides the score of each match: attributes (team attributes, form, morale and physical condition, default) or elo (Elo ratings)")
+	zonesFile := flag.String("zones", "", "JSON file with the qualification and relegation zones (see zones.json)")
+	divisions := flag.String("divisions", "", "Comma-separated team directories of each division, from top to bottom (e.g. datasets/2024/serie-a/,datasets/2024/serie-b/)")
+	numSeasons := flag.Int("seasons", 1, "Number of consecutive seasons, with promotion and relegation between divisions")
+	copaDoBrasil := flag.Bool("copa-do-brasil", false, "Play the Copa do Brasil (knockout, two-legged ties) alongside the league")
+	libertadores := flag.String("libertadores", "", "Play the Copa Libertadores alongside the league, with the foreign clubs of this directory (e.g. datasets/2024/libertadores/)")
+	stateChampionships := flag.String("state-championships", "", "Comma-separated format files of the state championships played before the league (see datasets/2024/state-championships/)")
+	calendarFile := flag.String("calendar", "", "JSON file with the season dates, midweek rounds and breaks (see calendar.json)")
+	squadsDir := flag.String("squads", "", "Directory with squad files, from which the starting XI of each match is picked (see squads/)")
+	eloRatingsFile := flag.String("elo-ratings", "", "JSON file with the initial Elo rating of each team (teams without one are rated from their attributes)")
+	teamsDir := flag.String("teams-dir", "", "Directory with the league teams (defaults to the dataset of the current season)")
+	season := flag.String("season", "", "Load the league teams of this season's dataset, from datasets/<season>/serie-a/ (e.g. 2024)")
+	datasetsDir := flag.String("datasets-dir", "", "Directory with the datasets (defaults to datasets/ in the working directory, or next to the executable)")
+	bye := flag.Bool("bye", false, "Allow an odd number of teams, one of them resting in each round")
+	events := flag.String("events", "", "Write domain events to stdout in this format (only ndjson is supported), instead of the human-oriented output")
 
 	flag.Parse()
 
-	simulation.Simulate(*nonInteractive, *gptApiKey, !*disableTerminalColors)
+	simulation.Simulate(simulation.Options{
+		NonInteractive:         *nonInteractive,
+		GptApiKey:              *gptApiKey,
+		EnableTerminalColors:   !*disableTerminalColors,
+		Seed:                   pickSeed(*seed),
+		ResumeFile:             *resumeFile,
+		FixturesFile:           *fixturesFile,
+		OutputFormat:           *outputFormat,
+		OutputFile:             *outputFile,
+		OutputDir:              *outputDir,
+		Events:                 *events,
+		TieBreakers:            *tieBreakers,
+		ScoreModel:             *scoreModel,
+		MatchModel:             *matchModel,
+		ZonesFile:              *zonesFile,
+		DivisionsDirs:          splitList(*divisions),
+		NumSeasons:             *numSeasons,
+		CopaDoBrasil:           *copaDoBrasil,
+		LibertadoresDir:        *libertadores,
+		StateChampionshipFiles: splitList(*stateChampionships),
+		CalendarFile:           *calendarFile,
+		SquadsDir:              *squadsDir,
+		EloRatingsFile:         *eloRatingsFile,
+		Bye:                    *bye,
+		TeamsDir:               *teamsDir,
+		Season:                 *season,
+		DatasetsDir:            *datasetsDir,
+	})
+}
+
+func monteCarlo(args []string) {
+	monteCarloFlags := flag.NewFlagSet("montecarlo", flag.ExitOnError)
+	numSeasons := monteCarloFlags.Int("n", 1000, "Number of seasons to simulate")
+	disableTerminalColors := monteCarloFlags.Bool("disable-terminal-colors", false, "Disable colors in the terminal output")
+	seed := monteCarloFlags.Uint64("seed", 0, "Seed for the random number generator (if 0, a random seed is picked)")
+	fixturesFile := monteCarloFlags.String("fixtures", "", "CSV file with the real schedule and results so far (round,home,away,home score,away score)")
+	zonesFile := monteCarloFlags.String("zones", "", "JSON file with the qualification and relegation zones (see zones.json)")
+	scoreModel := monteCarloFlags.String("score-model", "", "Model that draws the score of each match: poisson (default) or dixon-coles")
+	matchModel := monteCarloFlags.String("match-model", "", "Model that decides the score of each match: attributes (default) or elo")
+	bye := monteCarloFlags.Bool("bye", false, "Allow an odd number of teams, one of them resting in each round")
+	teamsDir := monteCarloFlags.String("teams-dir", "", "Directory with the league teams (defaults to the dataset of the current season)")
+	season := monteCarloFlags.String("season", "", "Load the league teams of this season's dataset, from datasets/<season>/serie-a/ (e.g. 2024)")
+	datasetsDir := monteCarloFlags.String("datasets-dir", "", "Directory with the datasets (defaults to datasets/ in the working directory, or next to the executable)")
+
+	monteCarloFlags.Parse(args)
+
+	simulation.MonteCarlo(simulation.MonteCarloOptions{
+		NumSeasons:           *numSeasons,
+		EnableTerminalColors: !*disableTerminalColors,
+		Seed:                 pickSeed(*seed),
+		FixturesFile:         *fixturesFile,
+		ZonesFile:            *zonesFile,
+		ScoreModel:           *scoreModel,
+		MatchModel:           *matchModel,
+		Bye:                  *bye,
+		TeamsDir:             *teamsDir,
+		Season:               *season,
+		DatasetsDir:          *datasetsDir,
+	})
+}
+
+func compareScoreModels(args []string) {
+	compareFlags := flag.NewFlagSet("scoremodels", flag.ExitOnError)
+	numSeasons := compareFlags.Int("n", 100, "Number of seasons to simulate with each score model")
+	seed := compareFlags.Uint64("seed", 0, "Seed for the random number generator (if 0, a random seed is picked)")
+	teamsDir := compareFlags.String("teams-dir", "", "Directory with the league teams (defaults to the dataset of the current season)")
+	season := compareFlags.String("season", "", "Load the league teams of this season's dataset, from datasets/<season>/serie-a/ (e.g. 2024)")
+	datasetsDir := compareFlags.String("datasets-dir", "", "Directory with the datasets (defaults to datasets/ in the working directory, or next to the executable)")
+
+	compareFlags.Parse(args)
+
+	simulation.CompareScoreModels(simulation.ScoreModelComparisonOptions{
+		NumSeasons:  *numSeasons,
+		Seed:        pickSeed(*seed),
+		TeamsDir:    *teamsDir,
+		Season:      *season,
+		DatasetsDir: *datasetsDir,
+	})
+}
+
+func fit(args []string) {
+	fitFlags := flag.NewFlagSet("fit", flag.ExitOnError)
+	matchesFile := fitFlags.String("matches", "", "CSV file with the past matches (round,home,away,home score,away score)")
+	teamsDir := fitFlags.String("teams-dir", "", "Directory with the current team files (defaults to the dataset of the current season)")
+	season := fitFlags.String("season", "", "Load the current team files of this season's dataset, from datasets/<season>/serie-a/ (e.g. 2024)")
+	datasetsDir := fitFlags.String("datasets-dir", "", "Directory with the datasets (defaults to datasets/ in the working directory, or next to the executable)")
+	outputDir := fitFlags.String("output-dir", "", "Write the fitted team files to this directory (if empty, the changes to the current files are printed)")
+
+	fitFlags.Parse(args)
+
+	simulation.Fit(simulation.FitOptions{
+		MatchesFile: *matchesFile,
+		TeamsDir:    *teamsDir,
+		Season:      *season,
+		DatasetsDir: *datasetsDir,
+		OutputDir:   *outputDir,
+	})
+}
+
+func backtest(args []string) {
+	backtestFlags := flag.NewFlagSet("backtest", flag.ExitOnError)
+	fixturesFile := backtestFlags.String("fixtures", "", "CSV file with the fixtures and results of a past season (round,home,away,home score,away score)")
+	numSimulations := backtestFlags.Int("n", 100, "Number of simulations of the remaining fixtures after each round")
+	seed := backtestFlags.Uint64("seed", 0, "Seed for the random number generator (if 0, a random seed is picked)")
+	matchModel := backtestFlags.String("match-model", "", "Model that decides the score of each match: attributes (default) or elo")
+	scoreModel := backtestFlags.String("score-model", "", "Model that draws the score of each match: poisson (default) or dixon-coles")
+	teamsDir := backtestFlags.String("teams-dir", "", "Directory with the league teams (defaults to the dataset of the current season)")
+	season := backtestFlags.String("season", "", "Load the league teams of this season's dataset, from datasets/<season>/serie-a/ (e.g. 2024)")
+	datasetsDir := backtestFlags.String("datasets-dir", "", "Directory with the datasets (defaults to datasets/ in the working directory, or next to the executable)")
+
+	backtestFlags.Parse(args)
+
+	simulation.Backtest(simulation.BacktestOptions{
+		FixturesFile:   *fixturesFile,
+		NumSimulations: *numSimulations,
+		Seed:           pickSeed(*seed),
+		MatchModel:     *matchModel,
+		ScoreModel:     *scoreModel,
+		TeamsDir:       *teamsDir,
+		Season:         *season,
+		DatasetsDir:    *datasetsDir,
+	})
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+func pickSeed(seed uint64) uint64 {
+	if seed == 0 {
+		return uint64(time.Now().UnixNano())
+	}
+	return seed
 }